@@ -0,0 +1,217 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// entry is a rendered log line together with its destinations.
+type entry struct {
+	level     LogLevel
+	line      string
+	toConsole bool
+	toFile    bool
+	toSinks   bool
+
+	// flush, if set, marks a Flush barrier: it is closed by the writer
+	// once all preceding entries are written. The entry carries no line.
+	flush chan struct{}
+}
+
+// asyncQueue is the buffered channel drained by the asynchronous writer goroutine.
+type asyncQueue struct {
+	ch      chan entry
+	policy  OverflowPolicy
+	dropped atomic.Uint64
+	done    chan struct{}
+
+	// mu guards closed against concurrent push, so nothing is sent on a closed channel.
+	mu     sync.RWMutex
+	closed bool
+}
+
+// SetAsync switches the global logger to asynchronous mode.
+// Does nothing if the logger is not initialized.
+func SetAsync(bufferSize int, policy OverflowPolicy) {
+	if l := getDefault(); l != nil {
+		l.SetAsync(bufferSize, policy)
+	}
+}
+
+// SetAsync switches this logger to asynchronous mode: log calls render the line
+// and push it onto a buffered channel of bufferSize entries, and a single background
+// goroutine writes the lines to console, file and sinks in order.
+// When the buffer is full the policy decides whether the caller waits (OverflowBlock)
+// or the line is dropped and counted (OverflowDrop). The writer goroutine holds the
+// logger lock while writing a line, so a stalled destination still delays log calls;
+// use AddSink for destinations that may stall.
+// bufferSize <= 0 switches back to synchronous mode. Pending lines are always
+// written before the previous queue is stopped; Close drains the queue as well.
+func (l *Logger) SetAsync(bufferSize int, policy OverflowPolicy) {
+	l.stopAsync()
+	if bufferSize <= 0 {
+		return
+	}
+
+	q := &asyncQueue{
+		ch:     make(chan entry, bufferSize),
+		policy: policy,
+		done:   make(chan struct{}),
+	}
+	go l.runAsync(q)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.async = q
+}
+
+// stopAsync waits until the async queue (if any) is drained, stops the writer and
+// detaches the queue. The queue stays attached while it is drained, so lines logged
+// meanwhile are written after the queued ones (see pushEntries).
+// If lines were dropped, a summary line is written synchronously afterwards.
+// Must not be called under l.mu.
+func (l *Logger) stopAsync() {
+	l.mu.Lock()
+	q := l.async
+	l.mu.Unlock()
+
+	if q == nil {
+		return
+	}
+	q.close()
+
+	l.mu.Lock()
+	detached := l.async == q
+	if detached {
+		l.async = nil
+	}
+	l.mu.Unlock()
+	if !detached {
+		// A concurrent stopAsync detached it and writes the summary
+		return
+	}
+
+	dropped := q.dropped.Load()
+	if dropped == 0 {
+		return
+	}
+	l.mu.Lock()
+	l.asyncDropped += dropped
+	l.mu.Unlock()
+	l.output(LevelWarn, unknownSource, fmt.Sprintf("async queue dropped %d messages", dropped))
+}
+
+// Dropped returns the number of lines the global logger dropped in async mode
+// because the buffer was full. Returns 0 if the logger is not initialized.
+func Dropped() uint64 {
+	l := getDefault()
+	if l == nil {
+		return 0
+	}
+	return l.Dropped()
+}
+
+// Dropped returns the number of lines dropped in async mode with OverflowDrop
+// because the buffer was full, over the lifetime of the logger.
+// Close writes a summary line if anything was dropped.
+func (l *Logger) Dropped() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n := l.asyncDropped
+	if l.async != nil {
+		n += l.async.dropped.Load()
+	}
+	return n
+}
+
+// runAsync writes queued entries until the queue is closed.
+func (l *Logger) runAsync(q *asyncQueue) {
+	defer close(q.done)
+	for e := range q.ch {
+		if e.flush != nil {
+			close(e.flush)
+			continue
+		}
+		l.mu.Lock()
+		l.writeEntryLocked(e)
+		l.mu.Unlock()
+		l.notifyRotations()
+	}
+}
+
+// pushEntries pushes entries returned by dispatch onto q. If q was closed in the
+// meantime, the remaining entries are written synchronously once the queued ones
+// are, so lines stay in order; after Close they are discarded.
+// Must not be called under l.mu.
+func (l *Logger) pushEntries(q *asyncQueue, entries []entry) {
+	for i, e := range entries {
+		if q.push(e) {
+			continue
+		}
+
+		<-q.done
+		l.mu.Lock()
+		if !l.closed.Load() {
+			for _, e := range entries[i:] {
+				l.writeEntryLocked(e)
+			}
+		}
+		l.mu.Unlock()
+		return
+	}
+}
+
+// push adds e to the queue according to the overflow policy.
+// It reports false if the queue is closed and e was not taken.
+func (q *asyncQueue) push(e entry) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return false
+	}
+
+	if q.policy == OverflowBlock {
+		q.ch <- e
+		return true
+	}
+
+	select {
+	case q.ch <- e:
+	default:
+		q.dropped.Add(1)
+	}
+	return true
+}
+
+// drain waits until all entries queued before the call are written
+// (or, if the queue is being closed, until all of them are).
+// Unlike push it always blocks for room in the queue, regardless of the policy.
+func (q *asyncQueue) drain() {
+	done := make(chan struct{})
+
+	q.mu.RLock()
+	if q.closed {
+		q.mu.RUnlock()
+		<-q.done
+		return
+	}
+	q.ch <- entry{flush: done}
+	q.mu.RUnlock()
+
+	<-done
+}
+
+// close stops accepting entries and waits until the queued ones are written.
+func (q *asyncQueue) close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.ch)
+	}
+	q.mu.Unlock()
+
+	<-q.done
+}
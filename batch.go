@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"bufio"
+	"io"
+	"time"
+)
+
+// defaultBatchInterval is used when SetBatching is called with a non-positive interval.
+const defaultBatchInterval = 200 * time.Millisecond
+
+// batchWriter coalesces file lines in a bufio.Writer bound to the current file.
+// It is guarded by l.mu, except for stop and done, which belong to the background flusher.
+type batchWriter struct {
+	w    *bufio.Writer
+	size int
+	// target is the file writer w currently writes to.
+	target io.Writer
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// SetBatching enables batched file writes of the global logger.
+// Does nothing if the logger is not initialized.
+func SetBatching(size int, interval time.Duration) {
+	if l := getDefault(); l != nil {
+		l.SetBatching(size, interval)
+	}
+}
+
+// SetBatching collects file lines in a bufio.Writer of size bytes and writes them
+// with a single call once the buffer is full or every interval, whichever comes first,
+// which reduces write syscalls when many goroutines log at once. Flush, Rotate, Reopen
+// and Close write the batch first. interval <= 0 means 200ms; size <= 0 writes
+// the pending batch and disables batching.
+// Lines written by a crashing process within the last interval may be lost.
+func (l *Logger) SetBatching(size int, interval time.Duration) {
+	l.stopBatch()
+	if size <= 0 {
+		return
+	}
+	if interval <= 0 {
+		interval = defaultBatchInterval
+	}
+
+	b := &batchWriter{
+		w:    bufio.NewWriterSize(nil, size),
+		size: size,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	l.mu.Lock()
+	l.batch = b
+	l.mu.Unlock()
+
+	go l.runBatch(b, interval)
+}
+
+// runBatch writes the batch every interval until it is stopped.
+func (l *Logger) runBatch(b *batchWriter, interval time.Duration) {
+	defer close(b.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			l.mu.Lock()
+			l.flushBatchLocked()
+			l.mu.Unlock()
+		case <-b.stop:
+			return
+		}
+	}
+}
+
+// stopBatch writes the pending batch, stops the background flusher and disables batching.
+// Must not be called under l.mu.
+func (l *Logger) stopBatch() {
+	l.mu.Lock()
+	b := l.batch
+	l.flushBatchLocked()
+	l.batch = nil
+	l.mu.Unlock()
+
+	if b != nil {
+		close(b.stop)
+		<-b.done
+	}
+}
+
+// batchLineLocked appends line to the batch; the buffer is written once it is full.
+// The batch is flushed before the file is swapped, so it is empty when the
+// file writer changes and only needs to be rebound.
+// Must be called under l.mu.
+func (l *Logger) batchLineLocked(line string) {
+	b := l.batch
+	if b.target != l.fileWriter {
+		b.target = l.fileWriter
+		b.w.Reset(b.target)
+	}
+	if _, err := b.w.WriteString(line); err != nil {
+		l.reportErrorLocked("write file", err)
+		// bufio keeps the error forever; drop the buffer and start over
+		b.w.Reset(b.target)
+	}
+}
+
+// flushBatchLocked writes the pending batch to the file it was collected for.
+// Must be called under l.mu.
+func (l *Logger) flushBatchLocked() {
+	b := l.batch
+	if b == nil || b.w.Buffered() == 0 {
+		return
+	}
+	if err := b.w.Flush(); err != nil {
+		l.reportErrorLocked("write file", err)
+		b.w.Reset(b.target)
+	}
+}
@@ -0,0 +1,198 @@
+package logger
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// callerConfig controls how the caller location of a line is looked up and rendered.
+type callerConfig struct {
+	// include enables the runtime.Caller lookup.
+	include bool
+	// function appends the calling function name: "app.go:42 (main.handleRequest)".
+	function bool
+	// path controls how the file is rendered (see SetCallerPathMode).
+	path CallerPathMode
+	// omitSource drops the source segment from lines and skips the lookup (see SetOmitSource).
+	omitSource bool
+	// stack captures the goroutine stack for lines at LevelError and above.
+	stack bool
+	// sourceLevels maps file base names to their minimum level (see SetSourceLevels).
+	// The map is never modified after it is set, so it can be read without l.mu.
+	sourceLevels map[string]LogLevel
+}
+
+// sourceEnabled reports whether a line at level passes the level of at least one file in sourceLevels.
+func (c callerConfig) sourceEnabled(level LogLevel) bool {
+	for _, min := range c.sourceLevels {
+		if level >= min {
+			return true
+		}
+	}
+	return false
+}
+
+// forSource applies the per-file level of file (see SetSourceLevels) to a line at level
+// that passed plan. It returns the logger to write the line with, or nil if the line
+// is dropped: the level of a matching file replaces the console and file levels,
+// other files are checked against those levels as usual.
+func (l *Logger) forSource(caller callerConfig, level LogLevel, file string) *Logger {
+	if len(caller.sourceLevels) == 0 {
+		return l
+	}
+	min, ok := caller.sourceLevels[filepath.Base(file)]
+	if !ok {
+		l.mu.Lock()
+		process := l.processLocked(level)
+		l.mu.Unlock()
+		if !process {
+			return nil
+		}
+		return l
+	}
+	if level < min {
+		return nil
+	}
+	child := l.clone()
+	child.sourceLevel = min
+	child.hasSourceLevel = true
+	return child
+}
+
+// render reports whether the caller is looked up and rendered in lines.
+func (c callerConfig) render() bool {
+	return c.include && !c.omitSource
+}
+
+// format renders the source info for a caller location.
+// pc may be 0 if the function name is unknown.
+func (c callerConfig) format(pc uintptr, file string, line int) string {
+	source := fmt.Sprintf("%s:%d", callerFile(c.path, pc, file), line)
+	if !c.function || pc == 0 {
+		return source
+	}
+
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return source
+	}
+	return fmt.Sprintf("%s (%s)", source, shortFuncName(fn.Name()))
+}
+
+// shortFuncName strips the package path from a fully qualified function name:
+// "github.com/org/app/server.(*Server).handle" -> "server.(*Server).handle".
+func shortFuncName(name string) string {
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// callerSettings returns the current caller configuration of l.
+func (l *Logger) callerSettings() callerConfig {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.caller
+}
+
+// SetIncludeFunction enables or disables the function name in the source info
+// of the global logger. Does nothing if the logger is not initialized.
+func SetIncludeFunction(enabled bool) {
+	if l := getDefault(); l != nil {
+		l.SetIncludeFunction(enabled)
+	}
+}
+
+// SetIncludeFunction enables or disables the calling function name in the source info,
+// e.g. "app.go:42 (main.handleRequest)". Disabled by default.
+func (l *Logger) SetIncludeFunction(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.caller.function = enabled
+}
+
+// SetOmitSource removes the source segment from lines of the global logger.
+// Does nothing if the logger is not initialized.
+func SetOmitSource(enabled bool) {
+	if l := getDefault(); l != nil {
+		l.SetOmitSource(enabled)
+	}
+}
+
+// SetOmitSource removes the source segment from lines: text lines read
+// "2006/01/02 15:04:05 INFO: msg", JSON and logfmt lines have no source key and GCP
+// lines no sourceLocation. Unlike SetIncludeCaller(false), which only skips the
+// lookup and renders "???", this changes the shape of the line; the lookup is
+// skipped as well. Disabled by default.
+func (l *Logger) SetOmitSource(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.caller.omitSource = enabled
+}
+
+// SetSourceLevels sets per-file minimum levels of the global logger.
+// Does nothing if the logger is not initialized.
+func SetSourceLevels(levels map[string]LogLevel) {
+	if l := getDefault(); l != nil {
+		l.SetSourceLevels(levels)
+	}
+}
+
+// SetSourceLevels sets minimum levels of individual source files without changing
+// the global levels: lines logged from a file whose base name is a key of levels
+// (e.g. "wrapper.go") use the mapped level instead of the console and file levels,
+// so a noisy file can be silenced and a file under investigation made more verbose.
+// Other files use the console and file levels as usual; sinks keep their own levels.
+// The caller is looked up even if SetIncludeCaller is disabled. The map is copied;
+// nil or an empty map removes all per-file levels.
+func (l *Logger) SetSourceLevels(levels map[string]LogLevel) {
+	var copied map[string]LogLevel
+	if len(levels) > 0 {
+		copied = make(map[string]LogLevel, len(levels))
+		for file, level := range levels {
+			copied[file] = level
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.caller.sourceLevels = copied
+}
+
+// sortedSourceFiles returns the keys of levels in alphabetical order.
+func sortedSourceFiles(levels map[string]LogLevel) []string {
+	files := make([]string, 0, len(levels))
+	for file := range levels {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// WithCallerSkip returns a child of the global logger that skips n additional
+// stack frames when reporting the caller. Returns nil (a no-op logger) if the
+// logger is not initialized.
+func WithCallerSkip(n int) *Logger {
+	return getDefault().WithCallerSkip(n)
+}
+
+// WithCallerSkip returns a child logger that skips n additional stack frames
+// when reporting the caller, so helpers wrapping the logger can attribute lines
+// to their own caller:
+//
+//	var log = logger.WithCallerSkip(1)
+//	func logInfo(msg string) { log.Info("%s", msg) } // reports the caller of logInfo
+//
+// Skips accumulate across nested calls. The default (0) reports the direct caller.
+func (l *Logger) WithCallerSkip(n int) *Logger {
+	if l == nil {
+		return nil
+	}
+
+	child := l.clone()
+	child.callerSkip += n
+	return child
+}
@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// CallerPathMode defines how the file of the caller is rendered in the source info.
+type CallerPathMode int
+
+const (
+	CallerPathBase  CallerPathMode = iota // handler.go:10 (default)
+	CallerPathShort                       // internal/api/handler.go:10, relative to the module root
+	CallerPathFull                        // /src/app/internal/api/handler.go:10
+)
+
+// SetCallerPathMode sets how the caller file is rendered by the global logger.
+// Does nothing if the logger is not initialized.
+func SetCallerPathMode(mode CallerPathMode) {
+	if l := getDefault(); l != nil {
+		l.SetCallerPathMode(mode)
+	}
+}
+
+// SetCallerPathMode sets how the caller file is rendered: its base name (default),
+// the path relative to the main module root, which tells apart equally named files
+// in a monorepo, or the full path. The short form is derived from the package of
+// the calling function; for code outside the main module (or when the module is
+// unknown, e.g. in tests) it falls back to the parent directory: "api/handler.go:10".
+func (l *Logger) SetCallerPathMode(mode CallerPathMode) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.caller.path = mode
+}
+
+// callerFile renders file according to mode; pc identifies the calling function.
+func callerFile(mode CallerPathMode, pc uintptr, file string) string {
+	switch mode {
+	case CallerPathFull:
+		return file
+	case CallerPathShort:
+		return shortCallerFile(pc, file)
+	}
+	return filepath.Base(file)
+}
+
+// shortCallerFile returns file relative to the main module root, e.g.
+// "internal/api/handler.go", or "api/handler.go" if that cannot be determined.
+func shortCallerFile(pc uintptr, file string) string {
+	base := filepath.Base(file)
+	if module := mainModulePath(); module != "" && pc != 0 {
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			pkg := funcPackagePath(fn.Name())
+			if pkg == module {
+				return base
+			}
+			if rel, ok := strings.CutPrefix(pkg, module+"/"); ok {
+				return rel + "/" + base
+			}
+		}
+	}
+	return filepath.Base(filepath.Dir(file)) + "/" + base
+}
+
+// funcPackagePath returns the import path of the package of a fully qualified
+// function name: "github.com/org/app/api.(*Server).handle" -> "github.com/org/app/api".
+func funcPackagePath(name string) string {
+	slash := strings.LastIndexByte(name, '/') + 1
+	if dot := strings.IndexByte(name[slash:], '.'); dot >= 0 {
+		return name[:slash+dot]
+	}
+	return name
+}
+
+var (
+	mainModuleOnce sync.Once
+	mainModule     string
+)
+
+// mainModulePath returns the module path of the running binary, or "" if unknown.
+func mainModulePath() string {
+	mainModuleOnce.Do(func() {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			mainModule = info.Main.Path
+		}
+	})
+	return mainModule
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Capture redirects all output of the global logger into an in-memory buffer
+// while fn runs and returns the captured lines (without trailing newlines).
+// The previous destinations are restored when fn returns, even if it panics.
+// Returns ErrNotInitialized if the logger is not initialized.
+func Capture(fn func()) ([]string, error) {
+	l := getDefault()
+	if l == nil {
+		return nil, ErrNotInitialized
+	}
+	return l.Capture(fn)
+}
+
+// Capture redirects all output of this logger into an in-memory buffer
+// while fn runs and returns the captured lines (without trailing newlines).
+// Lines that would go to both console and file are captured once.
+// Nested captures are supported: the inner capture receives the lines until it returns.
+func (l *Logger) Capture(fn func()) ([]string, error) {
+	var buf bytes.Buffer
+
+	l.mu.Lock()
+	prev := l.capture
+	l.capture = &buf
+	l.mu.Unlock()
+
+	func() {
+		defer func() {
+			l.mu.Lock()
+			l.capture = prev
+			l.mu.Unlock()
+		}()
+		fn()
+	}()
+
+	return splitLines(buf.String()), nil
+}
+
+// splitLines splits captured output into lines, dropping the trailing empty line.
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ColorMode controls ANSI colors of the level token in console output.
+type ColorMode int
+
+const (
+	ColorNever  ColorMode = iota // Never colorize (default)
+	ColorAuto                    // Colorize when the console writer is a terminal, honoring NO_COLOR and FORCE_COLOR
+	ColorAlways                  // Always colorize, e.g. for CI logs that render ANSI codes
+)
+
+// String returns the mode name, e.g. "Auto", or "ColorMode(n)" for an unknown value.
+func (mode ColorMode) String() string {
+	switch mode {
+	case ColorNever:
+		return "Never"
+	case ColorAuto:
+		return "Auto"
+	case ColorAlways:
+		return "Always"
+	}
+	return fmt.Sprintf("ColorMode(%d)", int(mode))
+}
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiGray   = "\x1b[90m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiRed    = "\x1b[31m"
+)
+
+// SetColorMode sets colorization of console output of the global logger.
+// File output is never colorized. Does nothing if the logger is not initialized.
+func SetColorMode(mode ColorMode) {
+	if l := getDefault(); l != nil {
+		l.SetColorMode(mode)
+	}
+}
+
+// SetColorMode sets colorization of the level token in console output of this logger:
+// gray TRACE/DEBUG, green INFO, yellow WARN, red ERROR. Only text format is colorized,
+// file output never is.
+func (l *Logger) SetColorMode(mode ColorMode) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.colorMode = mode
+}
+
+// levelColor returns the ANSI color sequence for level.
+func levelColor(level LogLevel) string {
+	switch level {
+	case LevelTrace, LevelDebug:
+		return ansiGray
+	case LevelInfo:
+		return ansiGreen
+	case LevelWarn:
+		return ansiYellow
+	case LevelError:
+		return ansiRed
+	}
+	return ""
+}
+
+// useColorLocked reports whether console output to w should be colorized.
+// Must be called under l.mu.
+func (l *Logger) useColorLocked(w io.Writer) bool {
+	if l.format != FormatText || l.formatter != nil {
+		return false
+	}
+	switch l.colorMode {
+	case ColorAlways:
+		return true
+	case ColorAuto:
+		return autoColor(w)
+	}
+	return false
+}
+
+// autoColor decides ColorAuto by the common conventions: NO_COLOR disables colors,
+// otherwise FORCE_COLOR enables them even when w is not a terminal (e.g. in CI),
+// otherwise colors are used only on a terminal. Empty variables are ignored;
+// FORCE_COLOR=0 or "false" does not force colors.
+func autoColor(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	switch force := os.Getenv("FORCE_COLOR"); force {
+	case "", "0", "false":
+	default:
+		return true
+	}
+	return isTerminal(w)
+}
+
+// colorizeLevel wraps the first occurrence of the level token in line with ANSI colors.
+func colorizeLevel(line string, level LogLevel) string {
+	color := levelColor(level)
+	token := levelName(level)
+	if color == "" {
+		return line
+	}
+	return strings.Replace(line, token, color+token+ansiReset, 1)
+}
+
+// isTerminal reports whether w is a character device such as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
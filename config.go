@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Config describes a logger as plain data, e.g. decoded from a configuration file.
+// The zero value is a console-only logger printing all levels.
+type Config struct {
+	OutputMode   OutputMode
+	ConsoleLevel LogLevel
+	FileLevel    LogLevel
+
+	// FilePath is the base path of log files, required for FileOnly and Both.
+	FilePath string
+	// MaxFileSize and MaxLines trigger rotation (0 disables them).
+	MaxFileSize int64
+	MaxLines    int64
+	// MaxBackups and MaxAge limit the rotated files kept (0 keeps all).
+	MaxBackups int
+	MaxAge     time.Duration
+
+	Format     Format
+	TimeFormat string
+	// Location is the time zone of timestamps; nil means time.Local.
+	Location *time.Location
+	// FileMode and DirMode are permissions of created files and directories (0 keeps the defaults).
+	FileMode os.FileMode
+	DirMode  os.FileMode
+
+	ColorMode     ColorMode
+	DefaultFields Fields
+}
+
+// Validate reports the first misconfiguration in c with a descriptive error.
+func (c Config) Validate() error {
+	switch c.OutputMode {
+	case ConsoleOnly:
+	case FileOnly, Both:
+		if c.FilePath == "" {
+			return fmt.Errorf("logger: file path required for %v mode", c.OutputMode)
+		}
+	default:
+		return fmt.Errorf("logger: invalid output mode %v", c.OutputMode)
+	}
+	if !c.ConsoleLevel.valid() {
+		return fmt.Errorf("logger: invalid console level %v", c.ConsoleLevel)
+	}
+	if !c.FileLevel.valid() {
+		return fmt.Errorf("logger: invalid file level %v", c.FileLevel)
+	}
+	if c.MaxFileSize < 0 {
+		return fmt.Errorf("logger: max file size must not be negative, got %d", c.MaxFileSize)
+	}
+	if c.MaxLines < 0 {
+		return fmt.Errorf("logger: max lines must not be negative, got %d", c.MaxLines)
+	}
+	if c.MaxBackups < 0 {
+		return fmt.Errorf("logger: max backups must not be negative, got %d", c.MaxBackups)
+	}
+	if c.MaxAge < 0 {
+		return fmt.Errorf("logger: max age must not be negative, got %v", c.MaxAge)
+	}
+	if c.Format < FormatText || c.Format > FormatLogfmt {
+		return fmt.Errorf("logger: invalid format %v", c.Format)
+	}
+	if err := validateTimeLayout(c.TimeFormat); err != nil {
+		return fmt.Errorf("logger: %w", err)
+	}
+	return nil
+}
+
+// options converts c to the equivalent options for NewWithOptions.
+func (c Config) options() []Option {
+	return []Option{
+		WithOutputMode(c.OutputMode),
+		WithConsoleLevel(c.ConsoleLevel),
+		WithFileLevel(c.FileLevel),
+		WithFile(c.FilePath),
+		WithMaxSize(c.MaxFileSize),
+		WithMaxLines(c.MaxLines),
+		WithMaxBackups(c.MaxBackups),
+		WithMaxAge(c.MaxAge),
+		WithFormat(c.Format),
+		WithTimeFormat(c.TimeFormat),
+		WithLocation(c.Location),
+		WithFilePermissions(c.FileMode, c.DirMode),
+		WithColorMode(c.ColorMode),
+		WithDefaultFields(c.DefaultFields),
+	}
+}
+
+// NewFromConfig validates c and creates an independent Logger from it.
+func NewFromConfig(c Config) (*Logger, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return NewWithOptions(c.options()...)
+}
+
+// InitFromConfig validates c and initializes the global logger from it.
+// Returns ErrAlreadyInitialized if the logger was already initialized.
+func InitFromConfig(c Config) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	return InitWithOptions(c.options()...)
+}
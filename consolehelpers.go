@@ -0,0 +1,31 @@
+package logger
+
+// SetConsoleHelpersRespectLevel makes ConsoleInfo and ConsoleSuccess of the global
+// logger honor the console level. Does nothing if the logger is not initialized.
+func SetConsoleHelpersRespectLevel(enabled bool) {
+	if l := getDefault(); l != nil {
+		l.SetConsoleHelpersRespectLevel(enabled)
+	}
+}
+
+// SetConsoleHelpersRespectLevel applies the console level threshold (see SetConsoleLevel)
+// to ConsoleInfo and ConsoleSuccess: with the threshold above LevelInfo their console
+// output is suppressed, while logging to file is unchanged. ConsoleError and ConsoleWarn
+// always print. Disabled by default, so the helpers print regardless of the level.
+func (l *Logger) SetConsoleHelpersRespectLevel(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.helpersRespectLevel = enabled
+}
+
+// consoleHelperVisible reports whether a Console* helper writing at level
+// is shown in the console. l may be nil.
+func (l *Logger) consoleHelperVisible(level LogLevel) bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return !l.helpersRespectLevel || level >= l.consoleLevel
+}
@@ -0,0 +1,48 @@
+package logger
+
+import "context"
+
+// fieldsKey is the context key of request-scoped fields.
+type fieldsKey struct{}
+
+// ContextWithFields returns a copy of ctx carrying fields in addition to the fields
+// already stored in ctx (new values win on key collision). Loggers obtained with
+// FromContext or WithContext attach them to every line, so handlers can propagate
+// e.g. trace_id without passing a logger through every call.
+func ContextWithFields(ctx context.Context, fields Fields) context.Context {
+	parent := FieldsFromContext(ctx)
+	merged := make(Fields, len(parent)+len(fields))
+	for k, v := range parent {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// FieldsFromContext returns the fields stored in ctx by ContextWithFields, or nil.
+// The returned map must not be modified.
+func FieldsFromContext(ctx context.Context) Fields {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey{}).(Fields)
+	return fields
+}
+
+// FromContext returns a child of the global logger that attaches the fields stored
+// in ctx. Returns nil (on which all logging methods are no-ops) if the logger is not initialized.
+func FromContext(ctx context.Context) *Logger {
+	return getDefault().WithContext(ctx)
+}
+
+// WithContext returns a child logger that attaches the fields stored in ctx
+// (see ContextWithFields). It returns l itself if ctx carries no fields.
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	fields := FieldsFromContext(ctx)
+	if l == nil || len(fields) == 0 {
+		return l
+	}
+	return l.WithFields(fields)
+}
@@ -0,0 +1,74 @@
+package logger
+
+import "fmt"
+
+// dedupeState tracks the last written message for SetDeduplicate.
+// It is guarded by l.mu.
+type dedupeState struct {
+	enabled bool
+
+	lastLevel LogLevel
+	lastKey   string
+	repeats   int
+}
+
+// SetDeduplicate enables or disables collapsing of identical consecutive messages
+// of the global logger. Does nothing if the logger is not initialized.
+func SetDeduplicate(enabled bool) {
+	if l := getDefault(); l != nil {
+		l.SetDeduplicate(enabled)
+	}
+}
+
+// SetDeduplicate enables or disables collapsing of identical consecutive messages
+// (same level, source, message and fields). Only the first occurrence is written;
+// the repeats are reported as "last message repeated K times" when a different
+// message arrives or on Flush and Close. Disabled by default.
+func (l *Logger) SetDeduplicate(enabled bool) {
+	l.flushRepeats()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.dedupe = dedupeState{enabled: enabled}
+}
+
+// dedupeLocked reports whether a message repeats the previous one. For a new message
+// it returns the entries summarizing the repeats of the previous one, if any.
+// Must be called under l.mu.
+func (l *Logger) dedupeLocked(level LogLevel, sourceInfo string, msg string) (duplicate bool, summary []entry) {
+	if !l.dedupe.enabled {
+		return false, nil
+	}
+
+	key := l.prefix + "\x00" + sourceInfo + "\x00" + msg + "\x00" + formatTextFields(l.fields, l.sliceSeparator)
+	if level == l.dedupe.lastLevel && key == l.dedupe.lastKey {
+		l.dedupe.repeats++
+		return true, nil
+	}
+
+	summary = l.repeatSummaryLocked()
+	l.dedupe.lastLevel = level
+	l.dedupe.lastKey = key
+	return false, summary
+}
+
+// repeatSummaryLocked returns the entry reporting pending repeats (or nil) and resets the counter.
+// Must be called under l.mu.
+func (l *Logger) repeatSummaryLocked() []entry {
+	if l.dedupe.repeats == 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("last message repeated %d times", l.dedupe.repeats)
+	l.dedupe.repeats = 0
+	return []entry{l.entryLocked(l.dedupe.lastLevel, unknownSource, msg)}
+}
+
+// flushRepeats writes the pending repeat summary, if any.
+// Must not be called under l.mu.
+func (l *Logger) flushRepeats() {
+	l.mu.Lock()
+	q, entries := l.routeLocked(l.repeatSummaryLocked())
+	l.mu.Unlock()
+
+	l.pushEntries(q, entries)
+}
@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiagnosticDump returns a human-readable report of the global logger's runtime state,
+// suitable for pasting into a support ticket.
+func DiagnosticDump() string {
+	l := getDefault()
+	if l == nil {
+		return "logger: not initialized\n"
+	}
+	return l.DiagnosticDump()
+}
+
+// DiagnosticDump returns a human-readable report of this logger's runtime state:
+// output mode, levels, format, active file, rotation and retention settings and sink health.
+func (l *Logger) DiagnosticDump() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("logger diagnostic dump\n")
+	fmt.Fprintf(&b, "  output mode:     %v\n", l.outputMode)
+	fmt.Fprintf(&b, "  console level:   %v\n", l.consoleLevel)
+	fmt.Fprintf(&b, "  console helpers: respect level=%t\n", l.helpersRespectLevel)
+	fmt.Fprintf(&b, "  file level:      %v\n", l.fileLevel)
+	fmt.Fprintf(&b, "  stderr level:    %v\n", l.stderrLevel)
+	fmt.Fprintf(&b, "  format:          %v\n", l.format)
+	fmt.Fprintf(&b, "  formatter:       %T\n", l.formatterLocked())
+	fmt.Fprintf(&b, "  time layout:     %q\n", l.textTimeLayout())
+	fmt.Fprintf(&b, "  time zone:       %v\n", l.nowLocked().Location())
+	fmt.Fprintf(&b, "  color mode:      %v\n", l.colorMode)
+	fmt.Fprintf(&b, "  include caller:  %t (function: %t, stack on error: %t, omit source: %t)\n", l.caller.include, l.caller.function, l.caller.stack, l.caller.omitSource)
+	for _, file := range sortedSourceFiles(l.caller.sourceLevels) {
+		fmt.Fprintf(&b, "  source level:    %s >= %v\n", file, l.caller.sourceLevels[file])
+	}
+	fmt.Fprintf(&b, "  base path:       %q\n", l.basePath)
+	fmt.Fprintf(&b, "  active file:     %q\n", l.filePath)
+	fmt.Fprintf(&b, "  collision limit: %d\n", l.collisionLimitLocked())
+	fmt.Fprintf(&b, "  symlink current: %t\n", l.symlinkCurrent)
+	fmt.Fprintf(&b, "  file open:       %t\n", l.fileWriter != nil)
+	fmt.Fprintf(&b, "  external writer: %t\n", l.externalWriter)
+	fmt.Fprintf(&b, "  extra writers:   %d\n", len(l.fileWriters))
+	for _, level := range []LogLevel{LevelTrace, LevelDebug, LevelInfo, LevelWarn, LevelError} {
+		if dest, ok := l.levelFiles[level]; ok {
+			fmt.Fprintf(&b, "  level file:      %v -> %q\n", level, dest.CurrentFilePath())
+		}
+	}
+	fmt.Fprintf(&b, "  permissions:     file %v, dir %v\n", l.fileMode, l.dirMode)
+	fmt.Fprintf(&b, "  current size:    %d bytes\n", l.currentSize)
+	fmt.Fprintf(&b, "  size resync:     every %d writes\n", l.resyncEvery)
+	fmt.Fprintf(&b, "  current lines:   %d\n", l.currentLines)
+	fmt.Fprintf(&b, "  max file size:   %d bytes\n", l.maxFileSize)
+	fmt.Fprintf(&b, "  max lines:       %d\n", l.maxLines)
+	fmt.Fprintf(&b, "  max backups:     %d\n", l.maxBackups)
+	fmt.Fprintf(&b, "  max age:         %v\n", l.maxAge)
+	fmt.Fprintf(&b, "  redaction:       %d field keys, %d patterns\n", len(l.redactKeys), len(l.redactPatterns))
+	fmt.Fprintf(&b, "  capture active:  %t\n", l.capture != nil)
+	fmt.Fprintf(&b, "  dry run:         %t (format issues: %d)\n", l.dryRun, len(l.formatIssues))
+	for _, level := range []LogLevel{LevelTrace, LevelDebug, LevelInfo, LevelWarn, LevelError} {
+		if r, ok := l.rateLimits[level]; ok {
+			fmt.Fprintf(&b, "  rate limit:      %v %d per %v (suppressed: %d)\n", level, r.limit, r.interval, r.suppressed)
+		}
+	}
+	if l.async != nil {
+		fmt.Fprintf(&b, "  async:           buffer=%d pending=%d policy=%v dropped=%d\n",
+			cap(l.async.ch), len(l.async.ch), l.async.policy, l.async.dropped.Load())
+	} else {
+		b.WriteString("  async:           off\n")
+	}
+	if l.batch != nil {
+		fmt.Fprintf(&b, "  batching:        size=%d pending=%d bytes\n", l.batch.size, l.batch.w.Buffered())
+	} else {
+		b.WriteString("  batching:        off\n")
+	}
+	fmt.Fprintf(&b, "  async dropped:   %d (previous queues)\n", l.asyncDropped)
+	fmt.Fprintf(&b, "  last error:      %v\n", l.lastError)
+	fmt.Fprintf(&b, "  written:         trace=%d debug=%d info=%d warn=%d error=%d\n",
+		l.levelCounts.load(LevelTrace), l.levelCounts.load(LevelDebug), l.levelCounts.load(LevelInfo),
+		l.levelCounts.load(LevelWarn), l.levelCounts.load(LevelError))
+	if l.syslog != nil {
+		fmt.Fprintf(&b, "  syslog:          level=%v\n", l.syslog.level)
+	} else {
+		b.WriteString("  syslog:          off\n")
+	}
+	fmt.Fprintf(&b, "  sinks:           %d\n", len(l.sinks))
+	for i, s := range l.sinks {
+		fmt.Fprintf(&b, "    sink %d: level=%v pending=%d dropped=%d errors=%d\n",
+			i, s.level, s.Pending(), s.Dropped(), s.Errors())
+	}
+	return b.String()
+}
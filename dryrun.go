@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxFormatIssues caps the number of recorded format issues to bound memory usage.
+const maxFormatIssues = 1000
+
+// SetDryRun enables or disables dry-run mode of the global logger.
+// Does nothing if the logger is not initialized.
+func SetDryRun(enabled bool) {
+	if l := getDefault(); l != nil {
+		l.SetDryRun(enabled)
+	}
+}
+
+// FormatIssues returns format mismatches recorded by the global logger in dry-run mode.
+// Returns nil if the logger is not initialized.
+func FormatIssues() []string {
+	l := getDefault()
+	if l == nil {
+		return nil
+	}
+	return l.FormatIssues()
+}
+
+// SetDryRun enables or disables dry-run mode. In dry-run mode every log call goes
+// through the full pipeline (formatting, filtering, line rendering) but nothing is
+// written anywhere; format strings that do not match their arguments are recorded
+// and can be read with FormatIssues. Enabling dry-run clears previously recorded issues.
+func (l *Logger) SetDryRun(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.dryRun = enabled
+	if enabled {
+		l.formatIssues = nil
+	}
+}
+
+// FormatIssues returns a copy of format mismatches recorded in dry-run mode,
+// e.g. `app.go:42: format "%d" produced "%!d(string=x)"`.
+func (l *Logger) FormatIssues() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.formatIssues...)
+}
+
+// checkFormat records a format issue if msg contains a fmt error marker
+// such as %!d(string=x), %!(EXTRA ...) or %!v(MISSING). Only active in dry-run mode.
+func (l *Logger) checkFormat(sourceInfo, format, msg string) {
+	if !strings.Contains(msg, "%!") {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.dryRun || len(l.formatIssues) >= maxFormatIssues {
+		return
+	}
+	l.formatIssues = append(l.formatIssues, fmt.Sprintf("%s: format %q produced %q", sourceInfo, format, msg))
+}
@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"io"
+	"os"
+)
+
+// SetEmoji enables or disables emoji in ConsoleError, ConsoleWarn, ConsoleInfo and ConsoleSuccess
+// of the global logger. Does nothing if the logger is not initialized.
+func SetEmoji(enabled bool) {
+	if l := getDefault(); l != nil {
+		l.SetEmoji(enabled)
+	}
+}
+
+// SetEmoji enables or disables emoji (❌, ⚠️, ℹ️, ✅) before the "Error:", "Warning:",
+// "Info:" and "Success:" labels of the Console* helpers. Even when enabled, emoji are shown only
+// on a terminal and only if the NO_EMOJI environment variable is not set, so redirected
+// output stays plain. Disabled by default.
+func (l *Logger) SetEmoji(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.emoji = enabled
+}
+
+// consoleLabel returns label for a Console* helper writing to w,
+// preceded by emoji if they are enabled and w is a terminal.
+// l may be nil.
+func (l *Logger) consoleLabel(w io.Writer, emoji, label string) string {
+	if l == nil {
+		return label
+	}
+
+	l.mu.Lock()
+	enabled := l.emoji
+	l.mu.Unlock()
+
+	if !enabled || os.Getenv("NO_EMOJI") != "" || !isTerminal(w) {
+		return label
+	}
+	return emoji + " " + label
+}
@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Environment variables read by ConfigFromEnv.
+const (
+	envLevel = "LOG_LEVEL"
+	envFile  = "LOG_FILE"
+	envMode  = "LOG_MODE"
+)
+
+// ParseOutputMode converts a case-insensitive mode name ("console", "file" or "both";
+// "consoleonly" and "fileonly" are accepted too) to an OutputMode.
+func ParseOutputMode(s string) (OutputMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "console", "consoleonly":
+		return ConsoleOnly, nil
+	case "file", "fileonly":
+		return FileOnly, nil
+	case "both":
+		return Both, nil
+	}
+	return ConsoleOnly, fmt.Errorf("unknown output mode %q", s)
+}
+
+// ConfigFromEnv builds a Config from LOG_LEVEL (the level of both console and file,
+// "info" by default), LOG_FILE (the base file path) and LOG_MODE ("console", "file"
+// or "both"; by default "both" if LOG_FILE is set and "console" otherwise).
+// Invalid values are reported with the variable name.
+func ConfigFromEnv() (Config, error) {
+	c := Config{ConsoleLevel: LevelInfo, FileLevel: LevelInfo}
+
+	if s := os.Getenv(envLevel); s != "" {
+		level, err := ParseLevel(s)
+		if err != nil {
+			return Config{}, fmt.Errorf("logger: %s: %w", envLevel, err)
+		}
+		c.ConsoleLevel, c.FileLevel = level, level
+	}
+
+	c.FilePath = os.Getenv(envFile)
+	if c.FilePath != "" {
+		c.OutputMode = Both
+	}
+
+	if s := os.Getenv(envMode); s != "" {
+		mode, err := ParseOutputMode(s)
+		if err != nil {
+			return Config{}, fmt.Errorf("logger: %s: %w", envMode, err)
+		}
+		if mode != ConsoleOnly && c.FilePath == "" {
+			return Config{}, fmt.Errorf("logger: %s=%s requires %s", envMode, s, envFile)
+		}
+		c.OutputMode = mode
+	}
+	return c, c.Validate()
+}
+
+// InitFromEnv initializes the global logger from environment variables (see ConfigFromEnv),
+// so twelve-factor apps need no configuration code.
+// Returns ErrAlreadyInitialized if the logger was already initialized.
+func InitFromEnv() error {
+	c, err := ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	return InitFromConfig(c)
+}
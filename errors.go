@@ -0,0 +1,49 @@
+package logger
+
+import "fmt"
+
+// OnError sets a callback of the global logger invoked when writing a line,
+// opening, rotating or pruning log files fails. Does nothing if the logger is not initialized.
+func OnError(fn func(error)) {
+	if l := getDefault(); l != nil {
+		l.OnError(fn)
+	}
+}
+
+// OnError sets a callback invoked when writing a line to the console or file,
+// opening or rotating the log file or deleting old files (see SetMaxBackups) fails,
+// e.g. on a full disk. nil removes it.
+// The callback runs under the logger lock: it must not log through the same
+// logger and should return quickly.
+func (l *Logger) OnError(fn func(error)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onError = fn
+}
+
+// LastError returns the last write, open or rotation error of the global logger,
+// or nil if there was none or the logger is not initialized.
+func LastError() error {
+	l := getDefault()
+	if l == nil {
+		return nil
+	}
+	return l.LastError()
+}
+
+// LastError returns the last write, open or rotation error, or nil if there was none.
+func (l *Logger) LastError() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lastError
+}
+
+// reportErrorLocked records err as the last error and passes it to the OnError callback.
+// Must be called under l.mu.
+func (l *Logger) reportErrorLocked(op string, err error) {
+	err = fmt.Errorf("logger: %s: %w", op, err)
+	l.lastError = err
+	if l.onError != nil {
+		l.onError(err)
+	}
+}
@@ -0,0 +1,35 @@
+package logger
+
+import "io"
+
+// AddWriter adds a writer receiving the same lines as the log file of the global logger.
+// Does nothing if the logger is not initialized.
+func AddWriter(w io.Writer) {
+	if l := getDefault(); l != nil {
+		l.AddWriter(w)
+	}
+}
+
+// AddWriter duplicates the file stream to w: every line written to the log file
+// (subject to the file level) is also written to w, synchronously and in the same order.
+// Rotation and retention apply only to the file owned by the logger; w is never closed.
+// A failing writer does not stop the others; its errors are reported via OnError.
+func (l *Logger) AddWriter(w io.Writer) {
+	if w == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fileWriters = append(l.fileWriters, w)
+}
+
+// writeFileWriters writes line to the writers added by AddWriter.
+// Must be called under l.mu.
+func (l *Logger) writeFileWriters(line string) {
+	for _, w := range l.fileWriters {
+		if _, err := io.WriteString(w, line); err != nil {
+			l.reportErrorLocked("write", err)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package logger
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Fields is a set of key/value pairs attached to log lines.
+// In text mode they are appended to the message as key=value,
+// in JSON mode they are merged into the JSON object. Keys are always
+// rendered in alphabetical order, so lines are deterministic and diffable.
+type Fields map[string]interface{}
+
+// WithFields returns a child of the global logger that attaches fields to every line.
+// Returns nil (on which all logging methods are no-ops) if the logger is not initialized.
+func WithFields(fields Fields) *Logger {
+	return getDefault().WithFields(fields)
+}
+
+// WithFields returns a child logger that attaches fields to every line in addition
+// to the fields of this logger. The child shares configuration and output with its parent.
+// Fields are copied, so the passed map may be reused and concurrent calls are safe.
+func (l *Logger) WithFields(fields Fields) *Logger {
+	if l == nil {
+		return nil
+	}
+
+	merged := make(Fields, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	child := l.clone()
+	child.fields = merged
+	return child
+}
+
+// Keys of the fields added by WithError.
+const (
+	errorFieldKey       = "error"
+	errorDetailFieldKey = "error_detail"
+)
+
+// WithError returns a child of the global logger that attaches err to every line.
+// Returns nil (on which all logging methods are no-ops) if the logger is not initialized.
+func WithError(err error) *Logger {
+	return getDefault().WithError(err)
+}
+
+// WithError returns a child logger that attaches err.Error() as the "error" field,
+// rendered as error=... in text mode and as a key of the object in JSON modes.
+// The message of an error wrapped with fmt.Errorf("...: %w", err) already carries
+// the whole chain. Errors with a richer %+v form (e.g. with a stack trace, as
+// produced by github.com/pkg/errors) also get it as the "error_detail" field.
+// A nil err returns l unchanged.
+func (l *Logger) WithError(err error) *Logger {
+	if err == nil {
+		return l
+	}
+
+	fields := Fields{errorFieldKey: err.Error()}
+	if _, ok := err.(fmt.Formatter); ok {
+		if detail := fmt.Sprintf("%+v", err); detail != err.Error() {
+			fields[errorDetailFieldKey] = detail
+		}
+	}
+	return l.WithFields(fields)
+}
+
+// SetDefaultFields sets static fields (e.g. service, host) attached to every line
+// of the global logger. Does nothing if the logger is not initialized.
+func SetDefaultFields(fields Fields) {
+	if l := getDefault(); l != nil {
+		l.SetDefaultFields(fields)
+	}
+}
+
+// SetDefaultFields sets static fields attached to every line of this logger and
+// all its children. Fields added by WithFields take precedence on key collision.
+// The map is copied; nil or an empty map removes the default fields.
+func (l *Logger) SetDefaultFields(fields Fields) {
+	var copied Fields
+	if len(fields) > 0 {
+		copied = make(Fields, len(fields))
+		for k, v := range fields {
+			copied[k] = v
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.defaultFields = copied
+}
+
+// recordFieldsLocked returns the fields of a line: the default fields
+// overridden by the fields of this logger.
+// Must be called under l.mu.
+func (l *Logger) recordFieldsLocked() Fields {
+	if len(l.defaultFields) == 0 {
+		return l.fields
+	}
+	if len(l.fields) == 0 {
+		return l.defaultFields
+	}
+
+	merged := make(Fields, len(l.defaultFields)+len(l.fields))
+	for k, v := range l.defaultFields {
+		merged[k] = v
+	}
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	return merged
+}
+
+// with returns a copy of fields with key set to value.
+func (fields Fields) with(key string, value interface{}) Fields {
+	merged := make(Fields, len(fields)+1)
+	for k, v := range fields {
+		merged[k] = v
+	}
+	merged[key] = value
+	return merged
+}
+
+// defaultSliceFieldSeparator is used to join slice field values in text mode.
+const defaultSliceFieldSeparator = ","
+
+// SetSliceFieldSeparator sets the separator used to join slice and array
+// field values in text and logfmt modes (e.g. "a,b,c" instead of "[a b c]").
+// Does nothing if the logger is not initialized.
+func SetSliceFieldSeparator(sep string) {
+	if l := getDefault(); l != nil {
+		l.SetSliceFieldSeparator(sep)
+	}
+}
+
+// SetSliceFieldSeparator sets the separator used to join slice and array
+// field values in text and logfmt modes of this logger.
+func (l *Logger) SetSliceFieldSeparator(sep string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sliceSeparator = sep
+}
+
+// formatFieldValue renders a field value for text output.
+// Slices and arrays are joined with sep, []byte is rendered as a string,
+// everything else uses the default fmt formatting.
+func formatFieldValue(v interface{}, sep string) string {
+	if v == nil {
+		return "<nil>"
+	}
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return fmt.Sprint(v)
+	}
+
+	parts := make([]string, rv.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(rv.Index(i).Interface())
+	}
+	return strings.Join(parts, sep)
+}
+
+// formatTextFields renders fields as " key=value key=value" sorted by key,
+// or an empty string if there are no fields.
+func formatTextFields(fields Fields, sep string) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	return string(appendTextFields(nil, fields, sep))
+}
+
+// appendTextFields appends fields rendered as in formatTextFields to dst.
+func appendTextFields(dst []byte, fields Fields, sep string) []byte {
+	if len(fields) == 0 {
+		return dst
+	}
+	for _, key := range sortedKeys(fields) {
+		dst = append(dst, ' ')
+		dst = append(dst, key...)
+		dst = append(dst, '=')
+		dst = append(dst, formatFieldValue(fields[key], sep)...)
+	}
+	return dst
+}
+
+// sortedKeys returns the keys of fields in alphabetical order.
+func sortedKeys(fields Fields) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"errors"
+	"os"
+)
+
+// WithFileHandle writes the file side of the output to an already open file, e.g. one
+// opened by the application or an fd inherited from systemd (os.NewFile(3, "app.log")).
+// Unlike WithWriter, size tracking and rotation work: the size is taken from Stat and
+// rotated files are created next to the base path, which defaults to f.Name() unless
+// WithFile is given. If owned is false the handle stays open after rotation and Close,
+// so the caller can keep using and closing it; otherwise the logger closes it.
+func WithFileHandle(f *os.File, owned bool) Option {
+	return func(l *Logger) error {
+		if f == nil {
+			return errors.New("logger: file handle is nil")
+		}
+		stat, err := f.Stat()
+		if err != nil {
+			return err
+		}
+
+		l.fileWriter = f
+		l.externalWriter = false
+		l.filePath = f.Name()
+		if l.basePath == "" {
+			l.basePath = f.Name()
+		}
+		l.currentSize = stat.Size()
+		if !owned {
+			l.borrowedFile = f
+		}
+		return nil
+	}
+}
+
+// closeFileHandleLocked closes file unless it is the caller-owned handle
+// passed to WithFileHandle, which is only released.
+// Must be called under l.mu.
+func (l *Logger) closeFileHandleLocked(file *os.File) error {
+	if file == l.borrowedFile {
+		l.borrowedFile = nil
+		return nil
+	}
+	return file.Close()
+}
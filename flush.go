@@ -0,0 +1,49 @@
+package logger
+
+import "os"
+
+// Flush writes pending asynchronous lines and commits the log file to stable storage.
+// It is a no-op in console-only mode. Returns nil if the logger is not initialized.
+func Flush() error {
+	l := getDefault()
+	if l == nil {
+		return nil
+	}
+	return l.Flush()
+}
+
+// Flush writes the pending "last message repeated" summary (see SetDeduplicate),
+// waits until all lines queued in async mode are written, writes the pending
+// batch (see SetBatching) and then calls
+// Sync on the log file, so its content survives a crash and can be read back
+// immediately. The sync is skipped in console-only mode and for custom writers.
+func (l *Logger) Flush() error {
+	l.flushRepeats()
+	l.drainAsync()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.flushBatchLocked()
+	for _, dest := range l.levelFiles {
+		if err := dest.Flush(); err != nil {
+			return err
+		}
+	}
+	if file, ok := l.fileWriter.(*os.File); ok && file != nil && !l.externalWriter {
+		return file.Sync()
+	}
+	return nil
+}
+
+// drainAsync waits for lines queued in async mode, if it is enabled.
+// Must not be called under l.mu.
+func (l *Logger) drainAsync() {
+	l.mu.Lock()
+	q := l.async
+	l.mu.Unlock()
+
+	if q != nil {
+		q.drain()
+	}
+}
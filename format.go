@@ -0,0 +1,367 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultTimeLayout is the timestamp layout of text lines.
+const defaultTimeLayout = "2006/01/02 15:04:05"
+
+// Special layouts accepted by SetTimeFormat in addition to Go reference time layouts.
+const (
+	TimeFormatUnix      = "unix"      // Seconds since the Unix epoch, e.g. 1700000000
+	TimeFormatUnixMilli = "unixmilli" // Milliseconds since the Unix epoch, e.g. 1700000000123
+)
+
+// Format defines how log lines are rendered.
+type Format int
+
+const (
+	FormatText   Format = iota // Plain text: "2006/01/02 15:04:05 LEVEL: file:line - msg"
+	FormatJSON                 // One JSON object per line with time, level, source and msg keys
+	FormatGCP                  // JSON with Google Cloud Logging keys (severity, message, sourceLocation)
+	FormatLogfmt               // logfmt: time=... level=info source=file:line msg="..." key=value
+)
+
+// String returns the format name, e.g. "JSON", or "Format(n)" for an unknown value.
+func (format Format) String() string {
+	switch format {
+	case FormatText:
+		return "Text"
+	case FormatJSON:
+		return "JSON"
+	case FormatGCP:
+		return "GCP"
+	case FormatLogfmt:
+		return "Logfmt"
+	}
+	return fmt.Sprintf("Format(%d)", int(format))
+}
+
+// SetFormat sets the output format of the global logger.
+// Applies to both console and file output. Does nothing if the logger is not initialized.
+func SetFormat(format Format) {
+	if l := getDefault(); l != nil {
+		l.SetFormat(format)
+	}
+}
+
+// SetFormat sets the output format of this logger for both console and file output.
+func (l *Logger) SetFormat(format Format) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.format = format
+}
+
+// SetTimeFormat sets the timestamp layout (Go reference time syntax) of text lines
+// of the global logger. An empty layout restores the default "2006/01/02 15:04:05".
+// Returns an error for obviously broken layouts or ErrNotInitialized.
+func SetTimeFormat(layout string) error {
+	l := getDefault()
+	if l == nil {
+		return ErrNotInitialized
+	}
+	return l.SetTimeFormat(layout)
+}
+
+// SetTimeFormat sets the timestamp layout (Go reference time syntax) of text lines,
+// e.g. time.RFC3339, or one of TimeFormatUnix and TimeFormatUnixMilli for epoch timestamps.
+// An empty layout restores the default "2006/01/02 15:04:05".
+// The layout is validated first; on error the current layout is kept.
+func (l *Logger) SetTimeFormat(layout string) error {
+	if err := validateTimeLayout(layout); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.timeLayout = layout
+	return nil
+}
+
+// SetLineEnding sets the line terminator of the global logger, e.g. "\r\n".
+// Does nothing if the logger is not initialized.
+func SetLineEnding(ending string) {
+	if l := getDefault(); l != nil {
+		l.SetLineEnding(ending)
+	}
+}
+
+// SetLineEnding sets the sequence terminating every line, e.g. "\r\n" for Windows
+// viewers. It applies to all formats including custom formatters; size-based rotation
+// counts the bytes actually written. An empty ending restores "\n".
+func (l *Logger) SetLineEnding(ending string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if ending == "\n" {
+		ending = ""
+	}
+	l.lineEnding = ending
+}
+
+// SetLevelWidth pads the level token of text lines of the global logger to width.
+// Does nothing if the logger is not initialized.
+func SetLevelWidth(width int) {
+	if l := getDefault(); l != nil {
+		l.SetLevelWidth(width)
+	}
+}
+
+// SetLevelWidth right-pads the level token of text lines with spaces to width,
+// e.g. 5 renders "INFO :" and "ERROR:" so the columns after the level line up.
+// Zero (the default) disables padding. Other formats are not affected.
+func (l *Logger) SetLevelWidth(width int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.levelWidth = width
+}
+
+// SetUTC switches timestamps of the global logger between UTC and local time.
+// Does nothing if the logger is not initialized.
+func SetUTC(enabled bool) {
+	if l := getDefault(); l != nil {
+		l.SetUTC(enabled)
+	}
+}
+
+// SetUTC switches timestamps between UTC and local time (the default).
+// It is a shorthand for SetLocation(time.UTC) and SetLocation(time.Local).
+func (l *Logger) SetUTC(enabled bool) {
+	if enabled {
+		l.SetLocation(time.UTC)
+	} else {
+		l.SetLocation(time.Local)
+	}
+}
+
+// SetLocation sets the time zone of timestamps of the global logger.
+// Does nothing if the logger is not initialized.
+func SetLocation(loc *time.Location) {
+	if l := getDefault(); l != nil {
+		l.SetLocation(loc)
+	}
+}
+
+// SetLocation sets the time zone of timestamps; nil restores time.Local.
+// It applies to log lines, file name suffixes and lifecycle events alike,
+// so file names and their contents agree.
+func (l *Logger) SetLocation(loc *time.Location) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.location = loc
+}
+
+// nowLocked returns the current time in the configured time zone.
+// Must be called under l.mu.
+func (l *Logger) nowLocked() time.Time {
+	if l.location == nil {
+		return l.now().In(time.Local)
+	}
+	return l.now().In(l.location)
+}
+
+// textTimeLayout returns the effective timestamp layout for text lines.
+// Must be called under l.mu.
+func (l *Logger) textTimeLayout() string {
+	layout := l.timeLayout
+	if layout == "" {
+		layout = defaultTimeLayout
+	}
+	return layoutWithPrecision(layout, l.timePrecision)
+}
+
+// formatTimestamp renders t with layout, handling the epoch special layouts.
+func formatTimestamp(t time.Time, layout string) string {
+	return string(appendTimestamp(nil, t, layout))
+}
+
+// appendTimestamp appends t rendered with layout to dst (see formatTimestamp).
+func appendTimestamp(dst []byte, t time.Time, layout string) []byte {
+	switch layout {
+	case TimeFormatUnix:
+		return strconv.AppendInt(dst, t.Unix(), 10)
+	case TimeFormatUnixMilli:
+		return strconv.AppendInt(dst, t.UnixMilli(), 10)
+	}
+	return t.AppendFormat(dst, layout)
+}
+
+// layoutProbe is formatted with layouts under validation. Every component has a
+// distinct two-digit value so repeated components can be spotted.
+var layoutProbe = time.Date(1999, time.November, 17, 20, 34, 58, 0, time.UTC)
+
+// layoutComponents maps components of layoutProbe to their rendered values.
+var layoutComponents = []struct {
+	name  string
+	value string
+}{
+	{"year", "1999"},
+	{"month", "11"},
+	{"day", "17"},
+	{"hour", "20"},
+	{"minute", "34"},
+	{"second", "58"},
+}
+
+// validateTimeLayout rejects layouts that contain no time components at all
+// or that render the same component more than once (e.g. "15:04:04").
+func validateTimeLayout(layout string) error {
+	if layout == "" || layout == TimeFormatUnix || layout == TimeFormatUnixMilli {
+		return nil
+	}
+
+	out := layoutProbe.Format(layout)
+	if out == layout {
+		return fmt.Errorf("invalid time layout %q: no time components, use Go reference time 2006-01-02 15:04:05", layout)
+	}
+
+	for _, c := range layoutComponents {
+		if strings.Count(out, c.value) > 1 {
+			return fmt.Errorf("invalid time layout %q: %s appears more than once (renders %q)", layout, c.name, out)
+		}
+	}
+	return nil
+}
+
+// formatJSONLine renders a log record as a single JSON object terminated by a newline.
+// Timestamps use layout (RFC3339 by default). Fields are merged into the object after the standard keys;
+// a field whose name clashes with a standard key is written as "fields.<name>".
+func formatJSONLine(t time.Time, layout, levelStr, sourceInfo, msg string, fields Fields) string {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	writeJSONPair(&buf, "time", t.Format(layout))
+	buf.WriteByte(',')
+	writeJSONPair(&buf, "level", levelStr)
+	buf.WriteByte(',')
+	if sourceInfo != "" {
+		writeJSONPair(&buf, "source", sourceInfo)
+		buf.WriteByte(',')
+	}
+	writeJSONPair(&buf, "msg", msg)
+
+	for _, key := range sortedKeys(fields) {
+		name := key
+		if isReservedJSONKey(key) {
+			name = "fields." + key
+		}
+		buf.WriteByte(',')
+		writeJSONPair(&buf, name, jsonFieldValue(fields[key]))
+	}
+
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// writeJSONPair writes "key":value, falling back to the fmt representation
+// of the value if it cannot be marshaled.
+func writeJSONPair(buf *bytes.Buffer, key string, value interface{}) {
+	k, _ := json.Marshal(key)
+	buf.Write(k)
+	buf.WriteByte(':')
+
+	v, err := json.Marshal(value)
+	if err != nil {
+		v, _ = json.Marshal(fmt.Sprint(value))
+	}
+	buf.Write(v)
+}
+
+// jsonFieldValue converts values that have no useful JSON form (errors) to strings.
+func jsonFieldValue(v interface{}) interface{} {
+	if err, ok := v.(error); ok {
+		return err.Error()
+	}
+	return v
+}
+
+// isReservedJSONKey reports whether key is one of the standard JSON line keys.
+func isReservedJSONKey(key string) bool {
+	switch key {
+	case "time", "level", "source", "msg":
+		return true
+	}
+	return false
+}
+
+// gcpSourceLocationKey is the key Cloud Logging reads the source location from.
+const gcpSourceLocationKey = "logging.googleapis.com/sourceLocation"
+
+// gcpSourceLocation is the value of gcpSourceLocationKey.
+type gcpSourceLocation struct {
+	File     string `json:"file"`
+	Line     string `json:"line"`
+	Function string `json:"function,omitempty"`
+}
+
+// parseGCPSourceLocation splits source info "file:line (function)" into its parts.
+func parseGCPSourceLocation(sourceInfo string) gcpSourceLocation {
+	var loc gcpSourceLocation
+	if i := strings.Index(sourceInfo, " ("); i >= 0 && strings.HasSuffix(sourceInfo, ")") {
+		loc.Function = sourceInfo[i+2 : len(sourceInfo)-1]
+		sourceInfo = sourceInfo[:i]
+	}
+
+	loc.File = sourceInfo
+	if i := strings.LastIndexByte(sourceInfo, ':'); i >= 0 {
+		loc.File, loc.Line = sourceInfo[:i], sourceInfo[i+1:]
+	}
+	return loc
+}
+
+// gcpSeverity maps a level onto a Cloud Logging severity.
+func gcpSeverity(level LogLevel) string {
+	switch level {
+	case LevelTrace, LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARNING"
+	case LevelError:
+		return "ERROR"
+	}
+	return "DEFAULT"
+}
+
+// formatGCPLine renders a log record as a JSON object understood by Cloud Logging
+// agents on GKE/Cloud Run: severity, message, time (RFC3339Nano) and sourceLocation.
+// Fields are merged into the object and end up in jsonPayload.
+func formatGCPLine(t time.Time, level LogLevel, sourceInfo, msg string, fields Fields) string {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	writeJSONPair(&buf, "severity", gcpSeverity(level))
+	buf.WriteByte(',')
+	writeJSONPair(&buf, "message", msg)
+	buf.WriteByte(',')
+	writeJSONPair(&buf, "time", t.Format(time.RFC3339Nano))
+	if sourceInfo != "" {
+		buf.WriteByte(',')
+		writeJSONPair(&buf, gcpSourceLocationKey, parseGCPSourceLocation(sourceInfo))
+	}
+
+	for _, key := range sortedKeys(fields) {
+		name := key
+		if isReservedGCPKey(key) {
+			name = "fields." + key
+		}
+		buf.WriteByte(',')
+		writeJSONPair(&buf, name, jsonFieldValue(fields[key]))
+	}
+
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// isReservedGCPKey reports whether key is one of the standard GCP line keys.
+func isReservedGCPKey(key string) bool {
+	switch key {
+	case "severity", "message", "time", gcpSourceLocationKey:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package logger
+
+import "time"
+
+// Formatter renders a single log record. Implement it to produce custom line shapes
+// (logfmt, CSV, ...) and install it with SetFormatter. The returned line must end
+// with a newline. Format is called under the logger lock and must not log itself.
+type Formatter interface {
+	Format(level LogLevel, t time.Time, source, msg string, fields Fields) []byte
+}
+
+// TextFormatter renders plain text lines: "2006/01/02 15:04:05 LEVEL: file:line - msg key=value".
+// An empty source omits the "file:line - " segment.
+type TextFormatter struct {
+	// Prefix is inserted right after the level: "LEVEL [api]: file:line - msg".
+	Prefix string
+	// TimeLayout is the timestamp layout (see SetTimeFormat); empty means "2006/01/02 15:04:05".
+	TimeLayout string
+	// SliceSeparator joins slice field values; empty means ",".
+	SliceSeparator string
+	// LevelWidth right-pads the level token with spaces to this width so columns
+	// align (width 5 renders "INFO :" and "ERROR:"); zero disables padding.
+	LevelWidth int
+}
+
+// Format implements Formatter.
+func (f TextFormatter) Format(level LogLevel, t time.Time, source, msg string, fields Fields) []byte {
+	return f.appendFormat(nil, level, t, source, msg, fields)
+}
+
+// appendFormat appends the rendered line to dst and returns the extended buffer.
+func (f TextFormatter) appendFormat(dst []byte, level LogLevel, t time.Time, source, msg string, fields Fields) []byte {
+	layout := f.TimeLayout
+	if layout == "" {
+		layout = defaultTimeLayout
+	}
+	sep := f.SliceSeparator
+	if sep == "" {
+		sep = defaultSliceFieldSeparator
+	}
+	dst = appendTimestamp(dst, t, layout)
+	dst = append(dst, ' ')
+	name := levelName(level)
+	dst = append(dst, name...)
+	for i := len(name); i < f.LevelWidth; i++ {
+		dst = append(dst, ' ')
+	}
+	if f.Prefix != "" {
+		dst = append(dst, ' ')
+		dst = append(dst, f.Prefix...)
+	}
+	dst = append(dst, ": "...)
+	if source != "" {
+		dst = append(dst, source...)
+		dst = append(dst, " - "...)
+	}
+	dst = append(dst, msg...)
+	dst = appendTextFields(dst, fields, sep)
+	return append(dst, '\n')
+}
+
+// JSONFormatter renders one JSON object per line with time, level, source and msg keys.
+// The source key is left out if the source is empty.
+type JSONFormatter struct {
+	// TimeLayout is the timestamp layout; empty means time.RFC3339.
+	TimeLayout string
+}
+
+// Format implements Formatter.
+func (f JSONFormatter) Format(level LogLevel, t time.Time, source, msg string, fields Fields) []byte {
+	layout := f.TimeLayout
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return []byte(formatJSONLine(t, layout, levelName(level), source, msg, fields))
+}
+
+// GCPFormatter renders JSON lines with Google Cloud Logging keys.
+type GCPFormatter struct{}
+
+// Format implements Formatter.
+func (GCPFormatter) Format(level LogLevel, t time.Time, source, msg string, fields Fields) []byte {
+	return []byte(formatGCPLine(t, level, source, msg, fields))
+}
+
+// SetFormatter sets a custom formatter of the global logger.
+// Does nothing if the logger is not initialized.
+func SetFormatter(f Formatter) {
+	if l := getDefault(); l != nil {
+		l.SetFormatter(f)
+	}
+}
+
+// SetFormatter replaces the built-in format with f for both console and file output.
+// nil restores the format chosen by SetFormat. Console colors apply only to
+// the built-in text format.
+func (l *Logger) SetFormatter(f Formatter) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.formatter = f
+}
+
+// formatterLocked returns the effective formatter: the custom one or
+// the built-in one for l.format.
+// Must be called under l.mu.
+func (l *Logger) formatterLocked() Formatter {
+	if l.formatter != nil {
+		return l.formatter
+	}
+	switch l.format {
+	case FormatJSON:
+		return JSONFormatter{TimeLayout: layoutWithPrecision(time.RFC3339, l.timePrecision)}
+	case FormatGCP:
+		return GCPFormatter{}
+	case FormatLogfmt:
+		return LogfmtFormatter{
+			TimeLayout:     layoutWithPrecision(time.RFC3339, l.timePrecision),
+			SliceSeparator: l.sliceSeparator,
+		}
+	}
+	return TextFormatter{TimeLayout: l.textTimeLayout(), SliceSeparator: l.sliceSeparator, LevelWidth: l.levelWidth}
+}
@@ -0,0 +1,91 @@
+package logger
+
+// SetLevelFile routes file output of level of the global logger to a separate file.
+// Returns ErrNotInitialized if the logger is not initialized.
+func SetLevelFile(level LogLevel, filePath string) error {
+	l := getDefault()
+	if l == nil {
+		return ErrNotInitialized
+	}
+	return l.SetLevelFile(level, filePath)
+}
+
+// SetLevelFile routes file output of level to its own files based on filePath
+// (e.g. logs/error.log for LevelError) instead of the main log file. The destination
+// has its own size tracking, rotation and timestamped names; it copies the rotation,
+// retention, permission and time zone settings of the main file at the time of the call.
+// Console output is not affected. An empty filePath routes level back to the main file.
+func (l *Logger) SetLevelFile(level LogLevel, filePath string) error {
+	l.mu.Lock()
+	var dest *Logger
+	if filePath != "" {
+		var err error
+		if dest, err = l.newLevelFileLocked(filePath); err != nil {
+			l.mu.Unlock()
+			return err
+		}
+	}
+
+	old := l.levelFiles[level]
+	if dest == nil {
+		delete(l.levelFiles, level)
+	} else {
+		if l.levelFiles == nil {
+			l.levelFiles = make(map[LogLevel]*Logger)
+		}
+		l.levelFiles[level] = dest
+	}
+	l.mu.Unlock()
+
+	if old != nil {
+		return old.Close()
+	}
+	return nil
+}
+
+// newLevelFileLocked creates the file-only logger backing a SetLevelFile destination.
+// Must be called under l.mu.
+func (l *Logger) newLevelFileLocked(filePath string) (*Logger, error) {
+	dest := &Logger{core: &core{
+		outputMode:     FileOnly,
+		basePath:       filePath,
+		maxFileSize:    l.maxFileSize,
+		maxLines:       l.maxLines,
+		maxBackups:     l.maxBackups,
+		maxAge:         l.maxAge,
+		fileMode:       l.fileMode,
+		dirMode:        l.dirMode,
+		location:       l.location,
+		symlinkCurrent: l.symlinkCurrent,
+		lifecycleSink:  l.lifecycleSink,
+		onError:        l.onError,
+		now:            l.now,
+	}}
+	if err := dest.openNewFileLocked(); err != nil {
+		return nil, err
+	}
+	return dest, nil
+}
+
+// writeLevelFile writes line to the destination of level, if there is one.
+// Must be called under l.mu.
+func (l *Logger) writeLevelFile(level LogLevel, line string) bool {
+	dest, ok := l.levelFiles[level]
+	if !ok {
+		return false
+	}
+
+	dest.mu.Lock()
+	defer dest.mu.Unlock()
+	dest.writeFile(line)
+	return true
+}
+
+// closeLevelFilesLocked closes all level destinations.
+// Must be called under l.mu.
+func (l *Logger) closeLevelFilesLocked() {
+	for level, dest := range l.levelFiles {
+		_ = dest.Close()
+		delete(l.levelFiles, level)
+	}
+}
@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+)
+
+// SetLifecycleSink sets a writer receiving internal lifecycle events of the global logger
+// (file open, rotation, pruning, close) as JSON lines, separately from application logs.
+// nil disables lifecycle events. Does nothing if the logger is not initialized.
+func SetLifecycleSink(w io.Writer) {
+	if l := getDefault(); l != nil {
+		l.SetLifecycleSink(w)
+	}
+}
+
+// SetLifecycleSink sets a writer receiving internal lifecycle events of this logger
+// as JSON lines: {"time":...,"event":"rotate","from":...,"to":...}.
+// An "attach" event describing the current configuration is written immediately.
+// nil disables lifecycle events.
+func (l *Logger) SetLifecycleSink(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.lifecycleSink = w
+	l.lifecycleLocked("attach", Fields{
+		"mode":     l.outputMode.String(),
+		"path":     l.filePath,
+		"max_size": l.maxFileSize,
+	})
+}
+
+// lifecycleLocked writes a lifecycle event to the lifecycle sink (if any).
+// Must be called under l.mu.
+func (l *Logger) lifecycleLocked(event string, attrs Fields) {
+	if l.lifecycleSink == nil {
+		return
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	writeJSONPair(&buf, "time", l.nowLocked().Format(timeRFC3339Milli))
+	buf.WriteByte(',')
+	writeJSONPair(&buf, "event", event)
+	for _, key := range sortedKeys(attrs) {
+		buf.WriteByte(',')
+		writeJSONPair(&buf, key, jsonFieldValue(attrs[key]))
+	}
+	buf.WriteString("}\n")
+
+	_, _ = l.lifecycleSink.Write(buf.Bytes())
+}
+
+// timeRFC3339Milli is RFC3339 with millisecond precision, used for lifecycle events.
+const timeRFC3339Milli = "2006-01-02T15:04:05.000Z07:00"
@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// LogfmtFormatter renders logfmt lines: time=... level=info source=app.go:42 msg="...".
+// The source pair is left out if the source is empty. Fields follow as extra
+// key=value pairs sorted by key; values containing spaces,
+// '=', quotes or control characters are quoted.
+type LogfmtFormatter struct {
+	// TimeLayout is the timestamp layout; empty means time.RFC3339.
+	TimeLayout string
+	// SliceSeparator joins slice field values; empty means ",".
+	SliceSeparator string
+}
+
+// Format implements Formatter.
+func (f LogfmtFormatter) Format(level LogLevel, t time.Time, source, msg string, fields Fields) []byte {
+	layout := f.TimeLayout
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	sep := f.SliceSeparator
+	if sep == "" {
+		sep = defaultSliceFieldSeparator
+	}
+	buf := make([]byte, 0, 128)
+	buf = appendLogfmtPair(buf, "time", t.Format(layout))
+	buf = append(buf, ' ')
+	buf = appendLogfmtPair(buf, "level", strings.ToLower(levelName(level)))
+	buf = append(buf, ' ')
+	if source != "" {
+		buf = appendLogfmtPair(buf, "source", source)
+		buf = append(buf, ' ')
+	}
+	buf = appendLogfmtPair(buf, "msg", msg)
+
+	for _, key := range sortedKeys(fields) {
+		name := logfmtKey(key)
+		if isReservedJSONKey(name) {
+			name = "fields." + name
+		}
+		buf = append(buf, ' ')
+		buf = appendLogfmtPair(buf, name, formatFieldValue(fields[key], sep))
+	}
+	return append(buf, '\n')
+}
+
+// appendLogfmtPair appends key=value to dst, quoting value if needed.
+func appendLogfmtPair(dst []byte, key, value string) []byte {
+	dst = append(dst, key...)
+	dst = append(dst, '=')
+	if logfmtNeedsQuote(value) {
+		return strconv.AppendQuote(dst, value)
+	}
+	return append(dst, value...)
+}
+
+// logfmtNeedsQuote reports whether value must be quoted: it is empty or contains
+// spaces, '=', '"' or non-printable characters.
+func logfmtNeedsQuote(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, r := range value {
+		if r == ' ' || r == '=' || r == '"' || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// logfmtKey replaces characters not allowed in logfmt keys (spaces, '=', '"'
+// and control characters) with '_'.
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '=' || r == '"' || !unicode.IsPrint(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
@@ -3,12 +3,17 @@
 package logger
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -20,8 +25,64 @@ const (
 	LevelInfo                  // Info level for general operational messages
 	LevelWarn                  // Warn level for warning conditions
 	LevelError                 // Error level for error conditions
+
+	// LevelTrace is below LevelDebug for very verbose output such as protocol dumps.
+	// It is written only when a threshold is lowered to LevelTrace.
+	LevelTrace = LevelDebug - 1
 )
 
+// unknownSource is rendered instead of file:line when the caller is not looked up.
+const unknownSource = "???"
+
+// levelName returns the token used for level in log lines.
+func levelName(level LogLevel) string {
+	switch level {
+	case LevelTrace:
+		return "TRACE"
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return "UNKNOWN"
+}
+
+// String returns the level name as it appears in log lines, e.g. "DEBUG",
+// or "LogLevel(n)" for an unknown value.
+func (level LogLevel) String() string {
+	if !level.valid() {
+		return fmt.Sprintf("LogLevel(%d)", int(level))
+	}
+	return levelName(level)
+}
+
+// valid reports whether level is one of the defined levels.
+func (level LogLevel) valid() bool {
+	return level >= LevelTrace && level <= LevelError
+}
+
+// ParseLevel converts a case-insensitive level name ("trace", "debug", "info",
+// "warn", "warning" or "error") to a LogLevel, e.g. for a value taken from an env variable.
+func ParseLevel(s string) (LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "trace":
+		return LevelTrace, nil
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q", s)
+}
+
 // OutputMode defines where log messages should be written.
 type OutputMode int
 
@@ -31,15 +92,87 @@ const (
 	Both                          // Log to both console and file
 )
 
+// String returns the mode name, e.g. "ConsoleOnly", or "OutputMode(n)" for an unknown value.
+func (mode OutputMode) String() string {
+	switch mode {
+	case ConsoleOnly:
+		return "ConsoleOnly"
+	case FileOnly:
+		return "FileOnly"
+	case Both:
+		return "Both"
+	}
+	return fmt.Sprintf("OutputMode(%d)", int(mode))
+}
+
+// valid reports whether mode is one of the defined output modes.
+func (mode OutputMode) valid() bool {
+	return mode >= ConsoleOnly && mode <= Both
+}
+
 // Logger is the main logger structure that manages log configuration and output.
+// Child loggers created by WithFields share the configuration and output of their parent.
 type Logger struct {
+	*core
+
+	// fields are attached to every line written by this logger.
+	// The map is owned by the logger and never modified after creation.
+	fields Fields
+
+	// callerSkip is the number of extra stack frames to skip when
+	// reporting the caller (see WithCallerSkip).
+	callerSkip int
+
+	// stack is the stack trace of the line being logged (see SetStackOnError).
+	stack string
+
+	// sourceLevel replaces the console and file levels for the line being logged
+	// if hasSourceLevel is set (see SetSourceLevels).
+	sourceLevel    LogLevel
+	hasSourceLevel bool
+
+	// prefix tags every line of this logger, e.g. "[api][auth]" (see WithPrefix).
+	prefix string
+}
+
+// clone returns a shallow copy of l sharing its core. Used to derive child loggers.
+func (l *Logger) clone() *Logger {
+	c := *l
+	return &c
+}
+
+// core holds configuration and output state shared between a logger and its children.
+type core struct {
 	consoleLevel LogLevel
 	fileLevel    LogLevel
 	outputMode   OutputMode
+	format       Format
+
+	// formatter overrides format when set (see SetFormatter).
+	formatter Formatter
+
+	// timeLayout is the timestamp layout for text lines (empty means defaultTimeLayout).
+	timeLayout string
+
+	// timePrecision adds fractional seconds to timestamps (see SetTimePrecision).
+	timePrecision TimePrecision
+
+	// levelWidth pads the level token of text lines (0 disables padding).
+	levelWidth int
+
+	// lineEnding replaces the trailing "\n" of every line (empty keeps "\n").
+	lineEnding string
+
+	// location is the time zone of timestamps in lines and file names (nil means time.Local).
+	location *time.Location
 
 	fileWriter  io.Writer
 	maxFileSize int64
 
+	// externalWriter is set when fileWriter was supplied by the caller
+	// (see NewWithWriter): it is never rotated or closed by the logger.
+	externalWriter bool
+
 	// baePath is the "template" path from config, e.g. logs/app.log
 	// Actual log files are created with timestamp suffix based on basePath.
 	basePath string
@@ -48,27 +181,224 @@ type Logger struct {
 	filePath string
 
 	currentSize int64
-	mu          sync.Mutex
+
+	// levelFiles route file output of some levels to separate files (see SetLevelFile).
+	levelFiles map[LogLevel]*Logger
+
+	// fileWriters receive a copy of every file line (see AddWriter).
+	fileWriters []io.Writer
+
+	// collisionLimit is the number of _NN suffixes tried for a taken file name
+	// (0 means defaultCollisionLimit, see SetCollisionLimit).
+	collisionLimit int
+
+	// borrowedFile is a caller-owned handle that is never closed (see WithFileHandle).
+	borrowedFile *os.File
+
+	// symlinkCurrent keeps basePath a symlink to filePath (see SetSymlinkCurrent).
+	symlinkCurrent bool
+
+	// fileMode and dirMode are the permissions of created log files and directories.
+	fileMode os.FileMode
+	dirMode  os.FileMode
+
+	// maxBackups is the number of rotated files to keep (0 keeps all).
+	maxBackups int
+	// maxAge deletes rotated files older than this (0 keeps all).
+	maxAge time.Duration
+
+	// maxLines rotates the file after this many lines (0 disables line-based rotation).
+	maxLines     int64
+	currentLines int64
+
+	// defaultFields are attached to every line (see SetDefaultFields).
+	defaultFields Fields
+
+	// resyncEvery is the number of writes between file size resyncs (see SetSizeResync).
+	resyncEvery       int
+	writesSinceResync int
+
+	// onRotate is called after rotations queued in rotations (see OnRotate).
+	onRotate         func(oldPath, newPath string)
+	rotations        []rotation
+	rotationsPending atomic.Bool
+
+	// batch, if set, coalesces file writes (see SetBatching).
+	batch *batchWriter
+
+	// redactKeys and redactPatterns mask sensitive data (see SetRedactFields).
+	redactKeys     map[string]bool
+	redactPatterns []*regexp.Regexp
+
+	// sliceSeparator joins slice field values in text and logfmt modes.
+	sliceSeparator string
+
+	// caller controls lookup and rendering of source info.
+	caller callerConfig
+
+	// dedupe collapses identical consecutive messages (see SetDeduplicate).
+	dedupe dedupeState
+
+	// maxMsgLen limits message length per level (see SetMaxMessageLength).
+	maxMsgLen map[LogLevel]int
+
+	// recent keeps the last written lines (see SetRecentBuffer).
+	recent *ringBuffer
+
+	// levelCounts counts written messages per level (see Stats).
+	levelCounts levelCounters
+
+	// dryRun discards all output; formatIssues collects format mismatches meanwhile.
+	dryRun       bool
+	formatIssues []string
+
+	// capture receives all lines instead of console/file while Capture runs.
+	capture io.Writer
+
+	// closed is set by Close; log calls are discarded afterwards.
+	closed atomic.Bool
+
+	// stdout and stderr are the console writers; levels from stderrLevel up go to stderr.
+	stdout      io.Writer
+	stderr      io.Writer
+	stderrLevel LogLevel
+	// colorMode controls ANSI colors in console output.
+	colorMode ColorMode
+
+	// async is the queue of the asynchronous writer (nil in synchronous mode).
+	async *asyncQueue
+	// asyncDropped counts lines dropped by previous async queues.
+	asyncDropped uint64
+
+	// sinks are additional asynchronous destinations (see AddSink).
+	sinks []*Sink
+
+	// rateLimits limit the number of messages per level (see SetRateLimit).
+	rateLimits map[LogLevel]*rateLimiter
+
+	// syslog is the syslog destination (see SetSyslog).
+	syslog *syslogOutput
+
+	// emoji enables emoji labels of the Console* helpers (see SetEmoji).
+	emoji bool
+
+	// helpersRespectLevel applies consoleLevel to ConsoleInfo and ConsoleSuccess
+	// (see SetConsoleHelpersRespectLevel).
+	helpersRespectLevel bool
+
+	// lifecycleSink receives internal lifecycle events (see SetLifecycleSink).
+	lifecycleSink io.Writer
+
+	// onError is called on write, open and rotation failures (see OnError);
+	// lastError keeps the last such failure.
+	onError   func(error)
+	lastError error
+
+	// now is the clock used for line timestamps, file names and retention.
+	// It is time.Now except in tests.
+	now func() time.Time
+
+	mu sync.Mutex
 }
 
 var (
 	defaultLogger *Logger
-	once          sync.Once
+
+	// defaultMu guards defaultLogger so Reset and MustReinit can swap it safely.
+	defaultMu sync.RWMutex
+)
+
+var (
+	// ErrAlreadyInitialized is returned by Init-family functions when the global logger
+	// is already initialized. Use Reset or MustReinit to reconfigure it intentionally.
+	ErrAlreadyInitialized = errors.New("logger is already initialized")
+
+	// ErrNotInitialized is returned by package-level functions that require Init to be called first.
+	ErrNotInitialized = errors.New("logger is not initialized")
+
+	// ErrNoLogFile is returned by OpenCurrentFile when no log file is open.
+	ErrNoLogFile = errors.New("logger: no log file is open")
 )
 
+// getDefault returns the current global logger or nil if it is not initialized.
+func getDefault() *Logger {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultLogger
+}
+
 // Init initializes the logger with the specified configuration.
 // outputMode determines where logs are written (console, file, or both).
 // consoleLevel sets the minimum log level for console output.
 // fileLevel sets the minimum log level for file output.
 // filePath specifies the log file path (required for file output modes).
 // maxFileSize sets the maximum log file size in bytes before rotation (0 disables rotation).
-// Returns an error if file initialization fails.
+// Returns an error if a file mode is selected without filePath, a level is invalid
+// or file initialization fails, or ErrAlreadyInitialized
+// if the logger was already initialized by a previous Init-family call.
 func Init(outputMode OutputMode, consoleLevel, fileLevel LogLevel, filePath string, maxFileSize int64) error {
-	var err error
-	once.Do(func() {
-		defaultLogger, err = newLogger(outputMode, consoleLevel, fileLevel, filePath, maxFileSize)
+	return initDefault(func() (*Logger, error) {
+		return newLogger(outputMode, consoleLevel, fileLevel, filePath, maxFileSize)
 	})
-	return err
+}
+
+// initDefault installs the logger returned by create as the global logger
+// unless one is already installed.
+func initDefault(create func() (*Logger, error)) error {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultLogger != nil {
+		return ErrAlreadyInitialized
+	}
+
+	l, err := create()
+	if err != nil {
+		return err
+	}
+	defaultLogger = l
+	return nil
+}
+
+// MustReinit closes the current global logger (if any) and the named loggers
+// (see GetLogger) and initializes a new one with the specified configuration.
+// Parameters have the same meaning as in Init.
+// Panics if the new logger cannot be created.
+func MustReinit(outputMode OutputMode, consoleLevel, fileLevel LogLevel, filePath string, maxFileSize int64) {
+	_ = closeRegistry()
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultLogger != nil {
+		_ = defaultLogger.Close()
+		defaultLogger = nil
+	}
+
+	l, err := newLogger(outputMode, consoleLevel, fileLevel, filePath, maxFileSize)
+	if err != nil {
+		panic(fmt.Sprintf("logger: reinit: %v", err))
+	}
+	defaultLogger = l
+}
+
+// ReplaceGlobal installs l as the global logger and returns a function restoring
+// the previous one (which may be nil). Neither logger is closed. Intended for tests
+// and for installing a logger built with New or NewWithWriter:
+//
+//	restore := logger.ReplaceGlobal(l)
+//	defer restore()
+func ReplaceGlobal(l *Logger) (restore func()) {
+	defaultMu.Lock()
+	prev := defaultLogger
+	defaultLogger = l
+	defaultMu.Unlock()
+
+	return func() {
+		defaultMu.Lock()
+		defaultLogger = prev
+		defaultMu.Unlock()
+	}
 }
 
 // InitConsoleOnly initializes a logger that writes only to console.
@@ -94,172 +424,848 @@ func InitBoth(consoleLevel, fileLevel LogLevel, filePath string, maxFileSize int
 	return Init(Both, consoleLevel, fileLevel, filePath, maxFileSize)
 }
 
-// Close closes underlying file writer (if any). Safe to call multiple times.
+// InitWithWriter initializes a logger that writes to console and to w instead of a file.
+// consoleLevel sets the minimum log level for console output.
+// fileLevel sets the minimum log level for output to w.
+// Rotation is disabled and Close never closes w.
+func InitWithWriter(consoleLevel, fileLevel LogLevel, w io.Writer) error {
+	return initDefault(func() (*Logger, error) {
+		return NewWithWriter(Both, consoleLevel, fileLevel, w)
+	})
+}
+
+// Close closes underlying file writer (if any) and the named loggers created by
+// SetLoggerConfig. Safe to call multiple times.
 func Close() error {
-	if defaultLogger == nil {
-		return nil
+	regErr := closeRegistry()
+
+	l := getDefault()
+	if l == nil {
+		return regErr
+	}
+	if err := l.Close(); err != nil {
+		return err
 	}
-	return defaultLogger.Close()
+	return regErr
 }
 
-// Close closes file resources of this logger (if any). Safe to call multiple times.
+// Reset closes the global logger and the named loggers (see GetLogger) and allows
+// a subsequent Init call to take effect; GetLogger then returns children of the new
+// global logger. Log calls made after Reset and before the next Init are silently discarded.
+func Reset() error {
+	regErr := closeRegistry()
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	var err error
+	if defaultLogger != nil {
+		err = defaultLogger.Close()
+	}
+	defaultLogger = nil
+	if err != nil {
+		return err
+	}
+	return regErr
+}
+
+// Close drains and stops sinks and closes file resources of this logger (if any).
+// Logging through the logger or its children after (or concurrently with) Close
+// is a no-op. Safe to call multiple times.
 func (l *Logger) Close() error {
+	if l.closed.Load() {
+		return nil
+	}
+	l.flushRepeats()
+
+	// Drain the async queue first: its writer needs l.mu
+	l.stopAsync()
+
+	// From here on log calls are discarded, so nothing reopens the file
+	if !l.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	l.stopBatch()
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if file, ok := l.fileWriter.(*os.File); ok {
-		err := file.Close()
+	l.closeSinksLocked()
+	_ = l.closeSyslogLocked()
+	l.closeLevelFilesLocked()
+	if l.fileWriter != nil {
+		l.lifecycleLocked("close", Fields{"path": l.filePath, "size": l.currentSize})
+	}
+
+	if file, ok := l.fileWriter.(*os.File); ok && !l.externalWriter {
+		err := l.closeFileHandleLocked(file)
 		l.fileWriter = nil
+		l.externalWriter = false
 		l.currentSize = 0
+		l.currentLines = 0
 		l.filePath = ""
 		return err
 	}
 	l.fileWriter = nil
+	l.externalWriter = false
 	l.currentSize = 0
+	l.currentLines = 0
 	l.filePath = ""
 	return nil
 }
 
-// newLogger creates a new Logger instance with the specified configuration.
-func newLogger(outputMode OutputMode, consoleLevel, fileLevel LogLevel, filePath string, maxFileSize int64) (*Logger, error) {
-	l := &Logger{
-		outputMode:   outputMode,
-		consoleLevel: consoleLevel,
-		fileLevel:    fileLevel,
-		basePath:     filePath,
-		maxFileSize:  maxFileSize,
-	}
-
-	// Create file writer if needed
-	if (outputMode == FileOnly || outputMode == Both) && filePath != "" {
-		if err := l.createFileWriter(); err != nil {
-			return nil, err
-		}
+// SetConsoleLevel changes the minimum log level for console output at runtime.
+// Does nothing if the logger is not initialized.
+func SetConsoleLevel(level LogLevel) {
+	if l := getDefault(); l != nil {
+		l.SetConsoleLevel(level)
+	}
+}
+
+// SetFileLevel changes the minimum log level for file output at runtime.
+// Does nothing if the logger is not initialized.
+func SetFileLevel(level LogLevel) {
+	if l := getDefault(); l != nil {
+		l.SetFileLevel(level)
+	}
+}
+
+// GetConsoleLevel returns the current minimum log level for console output.
+// Returns LevelDebug if the logger is not initialized.
+func GetConsoleLevel() LogLevel {
+	l := getDefault()
+	if l == nil {
+		return LevelDebug
+	}
+	return l.GetConsoleLevel()
+}
+
+// GetFileLevel returns the current minimum log level for file output.
+// Returns LevelDebug if the logger is not initialized.
+func GetFileLevel() LogLevel {
+	l := getDefault()
+	if l == nil {
+		return LevelDebug
 	}
+	return l.GetFileLevel()
+}
 
-	return l, nil
+// SetConsoleLevel changes the minimum log level for console output of this logger.
+// The open file handle (if any) is kept.
+func (l *Logger) SetConsoleLevel(level LogLevel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.consoleLevel = level
 }
 
-// createFileWriter initializes the log file and directory structure.
-func (l *Logger) createFileWriter() error {
-	dir := filepath.Dir(l.basePath)
-	if dir != "." && dir != string(filepath.Separator) {
-		if err := os.MkdirAll(dir, 0755); err != nil {
+// SetFileLevel changes the minimum log level for file output of this logger.
+// The open file handle (if any) is kept.
+func (l *Logger) SetFileLevel(level LogLevel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fileLevel = level
+}
+
+// SetOutputMode changes the output mode of the global logger at runtime.
+// Returns ErrNotInitialized if the logger is not initialized.
+func SetOutputMode(mode OutputMode) error {
+	l := getDefault()
+	if l == nil {
+		return ErrNotInitialized
+	}
+	return l.SetOutputMode(mode)
+}
+
+// SetOutputMode changes the output mode of this logger at runtime, e.g. to start
+// writing a file when an operator enables it. Switching into FileOnly or Both opens
+// a new file from the configured base path, so it fails if no path (or writer) was
+// configured; switching to ConsoleOnly writes pending lines and closes the log file.
+// The base path is kept, so the file mode can be enabled again later.
+func (l *Logger) SetOutputMode(mode OutputMode) error {
+	if !mode.valid() {
+		return fmt.Errorf("logger: invalid output mode %v", mode)
+	}
+
+	// Lines routed with the old mode must be written first
+	l.drainAsync()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if mode == ConsoleOnly {
+		l.closeFileLocked()
+		l.outputMode = mode
+		return nil
+	}
+	if l.fileWriter == nil {
+		if l.basePath == "" {
+			return fmt.Errorf("logger: file path required for %v mode", mode)
+		}
+		if err := l.openNewFileLocked(); err != nil {
 			return err
 		}
 	}
+	l.outputMode = mode
+	return nil
+}
 
-	path, err := uniqueLogPath(l.basePath)
-	if err != nil {
-		return err
+// closeFileLocked writes the pending batch and closes the log file unless it is
+// an external writer, which is kept.
+// Must be called under l.mu.
+func (l *Logger) closeFileLocked() {
+	l.flushBatchLocked()
+	file, ok := l.fileWriter.(*os.File)
+	if !ok || file == nil || l.externalWriter {
+		return
 	}
+	l.lifecycleLocked("close", Fields{"path": l.filePath, "size": l.currentSize})
+	if err := l.closeFileHandleLocked(file); err != nil {
+		l.reportErrorLocked("close file", err)
+	}
+	l.fileWriter = nil
+	l.filePath = ""
+	l.currentSize = 0
+	l.currentLines = 0
+}
 
-	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		return err
+// GetConsoleLevel returns the current minimum log level for console output of this logger.
+func (l *Logger) GetConsoleLevel() LogLevel {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.consoleLevel
+}
+
+// GetFileLevel returns the current minimum log level for file output of this logger.
+func (l *Logger) GetFileLevel() LogLevel {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.fileLevel
+}
+
+// GetOutputMode returns the current output mode of the global logger.
+// Returns ConsoleOnly if the logger is not initialized.
+func GetOutputMode() OutputMode {
+	l := getDefault()
+	if l == nil {
+		return ConsoleOnly
 	}
+	return l.GetOutputMode()
+}
 
-	stat, err := file.Stat()
-	if err != nil {
-		_ = file.Close()
-		return err
+// GetOutputMode returns the current output mode of this logger.
+func (l *Logger) GetOutputMode() OutputMode {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.outputMode
+}
+
+// HasFileWriter reports whether the global logger really writes a file.
+// Returns false if the logger is not initialized.
+func HasFileWriter() bool {
+	l := getDefault()
+	if l == nil {
+		return false
 	}
+	return l.HasFileWriter()
+}
 
-	l.currentSize = stat.Size()
-	l.fileWriter = file
-	l.filePath = path
-	return nil
+// HasFileWriter reports whether file output is active: the mode is FileOnly or Both
+// and a file (or custom writer) is open. Use it at startup to assert that file logging
+// is really on; it is false, for example, after the log file failed to open.
+func (l *Logger) HasFileWriter() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return (l.outputMode == FileOnly || l.outputMode == Both) && l.fileWriter != nil
+}
+
+// setClock replaces the clock of this logger. Intended for tests.
+func (l *Logger) setClock(now func() time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.now = now
+}
+
+// New creates an independent Logger instance that is not tied to the global logger.
+// Parameters have the same meaning as in Init. The caller is responsible for
+// calling Close on the returned logger when file output is used.
+func New(outputMode OutputMode, consoleLevel, fileLevel LogLevel, filePath string, maxFileSize int64) (*Logger, error) {
+	return newLogger(outputMode, consoleLevel, fileLevel, filePath, maxFileSize)
+}
+
+// SetMaxLines makes the global logger rotate the log file after n lines
+// in addition to the size limit (whichever is hit first). 0 disables line-based rotation.
+// Does nothing if the logger is not initialized.
+func SetMaxLines(n int64) {
+	if l := getDefault(); l != nil {
+		l.SetMaxLines(n)
+	}
+}
+
+// SetMaxLines makes this logger rotate the log file after n lines
+// in addition to the size limit (whichever is hit first). 0 disables line-based rotation.
+func (l *Logger) SetMaxLines(n int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxLines = n
 }
 
-func (l *Logger) formatLine(levelStr string, sourceInfo string, msg string) string {
-	return fmt.Sprintf("%s %s: %s - %s\n", time.Now().Format("2006/01/02 15:04:05"), levelStr, sourceInfo, msg)
+// NewWithWriter creates an independent Logger that writes the file side of its output
+// to w instead of a file (e.g. a bytes.Buffer, a pipe or a network connection).
+// outputMode selects whether w (FileOnly), the console (ConsoleOnly) or both are used.
+// Rotation is disabled since a generic writer cannot be reopened, and Close never closes w.
+func NewWithWriter(outputMode OutputMode, consoleLevel, fileLevel LogLevel, w io.Writer) (*Logger, error) {
+	return NewWithOptions(WithOutputMode(outputMode), WithConsoleLevel(consoleLevel), WithFileLevel(fileLevel), WithWriter(w))
+}
+
+// newLogger creates a new Logger instance with the specified configuration.
+func newLogger(outputMode OutputMode, consoleLevel, fileLevel LogLevel, filePath string, maxFileSize int64) (*Logger, error) {
+	return NewWithOptions(WithOutputMode(outputMode), WithConsoleLevel(consoleLevel), WithFileLevel(fileLevel),
+		WithFile(filePath), WithMaxSize(maxFileSize))
+}
+
+// formatLine renders a line with the effective formatter, fields, stack and prefix.
+// A panic while rendering (in a custom Formatter or a field's MarshalJSON, for example)
+// is turned into a plain text line with a %!PANIC placeholder instead of crashing the caller.
+// Must be called under l.mu.
+func (l *Logger) formatLine(level LogLevel, sourceInfo string, msg string) (line string) {
+	if l.caller.omitSource {
+		sourceInfo = ""
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			line = string(TextFormatter{}.Format(level, l.nowLocked(), sourceInfo,
+				fmt.Sprintf("%s %%!PANIC(%v)", msg, r), nil))
+		}
+	}()
+
+	f := l.formatterLocked()
+	msg, fields := l.redactLocked(msg, l.recordFieldsLocked())
+	text, isText := f.(TextFormatter)
+	if l.stack != "" {
+		if isText {
+			msg += "\n" + l.stack
+		} else {
+			fields = fields.with(StackField, l.stack)
+		}
+	}
+	if l.prefix != "" {
+		if isText {
+			text.Prefix = l.prefix
+		} else {
+			msg = l.prefix + " " + msg
+		}
+	}
+	if !isText {
+		line := string(f.Format(level, l.nowLocked(), sourceInfo, msg, fields))
+		if l.lineEnding != "" && strings.HasSuffix(line, "\n") {
+			line = line[:len(line)-1] + l.lineEnding
+		}
+		return line
+	}
+
+	// The built-in text format renders into a pooled buffer: the resulting
+	// string is the only allocation besides the message itself
+	buf := getLineBuffer()
+	defer putLineBuffer(buf)
+	*buf = text.appendFormat(*buf, level, l.nowLocked(), sourceInfo, msg, fields)
+	if l.lineEnding != "" {
+		*buf = append((*buf)[:len(*buf)-1], l.lineEnding...)
+	}
+	return string(*buf)
 }
 
 func (l *Logger) writeConsole(level LogLevel, line string) {
-	_, _ = io.WriteString(getConsoleWriter(level), line)
+	w := l.consoleWriterLocked(level)
+	if l.useColorLocked(w) {
+		line = colorizeLevel(line, level)
+	}
+	if _, err := io.WriteString(w, line); err != nil {
+		l.reportErrorLocked("write console", err)
+	}
 }
 
 func (l *Logger) writeFile(line string) {
 	if l.fileWriter == nil {
-		_ = l.openNewFileLocked()
+		if err := l.openNewFileLocked(); err != nil {
+			l.reportErrorLocked("open file", err)
+		}
 		if l.fileWriter == nil {
 			return
 		}
 	}
 
+	l.resyncSizeLocked()
 	nextBytes := int64(len(line))
 	if l.shouldRotate(nextBytes) {
-		_ = l.rotateLocked()
-		if l.fileWriter == nil {
-			return
+		// A failed rotation keeps the current file open, so the line is written
+		// there instead of being lost; the rotation is retried on the next write.
+		// Either way the line lands in exactly one file.
+		if err := l.rotateLocked(); err != nil {
+			l.reportErrorLocked("rotate", err)
 		}
 	}
 
+	if l.batch != nil {
+		l.batchLineLocked(line)
+		l.currentSize += int64(len(line))
+		l.currentLines++
+		return
+	}
+
 	n, err := io.WriteString(l.fileWriter, line)
-	if err == nil {
-		l.currentSize += int64(n)
+	if err != nil {
+		l.reportErrorLocked("write file", err)
+		return
 	}
+	l.currentSize += int64(n)
+	l.currentLines++
 }
 
 // log is the internal method that handles actual log message processing and output.
-func (l *Logger) log(level LogLevel, levelStr string, format string, v ...interface{}) {
+func (l *Logger) log(level LogLevel, format string, v ...interface{}) {
+	if l == nil || l.closed.Load() {
+		return
+	}
+
+	// Filter before any formatting or caller lookup: both are expensive
+	process, caller := l.plan(level)
+	if !process {
+		return
+	}
+
+	sourceInfo := unknownSource
+	if caller.render() || len(caller.sourceLevels) > 0 {
+		pc, file, line, _ := runtime.Caller(2 + l.callerSkip)
+		if l = l.forSource(caller, level, file); l == nil {
+			return
+		}
+		if caller.render() {
+			sourceInfo = caller.format(pc, file, line)
+		}
+	}
+
+	msg := fmt.Sprintf(format, v...)
+	l.checkFormat(sourceInfo, format, msg)
+	if caller.stack && level >= LevelError {
+		l = l.withStack(captureStack(2 + l.callerSkip))
+	}
+	l.output(level, sourceInfo, msg)
+}
+
+// output formats a message with already resolved source info and writes it
+// to all enabled destinations.
+func (l *Logger) output(level LogLevel, sourceInfo string, msg string) {
+	q, entries := l.dispatch(level, sourceInfo, msg)
+	// Pushed outside l.mu: the async writer needs the lock to drain the queue
+	l.pushEntries(q, entries)
+	l.notifyRotations()
+}
+
+// dispatch applies deduplication and rate limits, renders the lines and
+// routes them to their destinations (see routeLocked).
+func (l *Logger) dispatch(level LogLevel, sourceInfo string, msg string) (*asyncQueue, []entry) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	msg := fmt.Sprintf(format, v...)
-	_, file, line, _ := runtime.Caller(2)
-	fileName := filepath.Base(file)
-	sourceInfo := fmt.Sprintf("%s:%d", fileName, line)
+	// Rechecked under the lock: Close may have run since the check in log
+	if l.closed.Load() {
+		return nil, nil
+	}
 
-	logLine := l.formatLine(levelStr, sourceInfo, msg)
+	duplicate, entries := l.dedupeLocked(level, sourceInfo, msg)
+	if duplicate {
+		return nil, nil
+	}
+
+	allowed, summary := l.rateLimitLocked(level)
+	if !allowed {
+		return l.routeLocked(entries)
+	}
+	if summary != "" {
+		entries = append(entries, l.entryLocked(level, unknownSource, summary))
+	}
+	entries = append(entries, l.entryLocked(level, sourceInfo, l.truncateMessageLocked(level, msg)))
+	if e := entries[len(entries)-1]; !l.dryRun && (e.toConsole || e.toFile || e.toSinks) {
+		l.levelCounts.add(level)
+	}
+
+	return l.routeLocked(entries)
+}
+
+// routeLocked writes rendered entries synchronously, or, in async mode,
+// returns the queue and the entries the caller must push after releasing l.mu.
+// Must be called under l.mu.
+func (l *Logger) routeLocked(entries []entry) (*asyncQueue, []entry) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	// Dry-run: the line is fully rendered but not written anywhere
+	if l.dryRun {
+		return nil, nil
+	}
+
+	// Redirect everything into the capture buffer while Capture is running
+	if l.capture != nil {
+		for _, e := range entries {
+			if e.toConsole || e.toFile || e.toSinks {
+				_, _ = io.WriteString(l.capture, e.line)
+			}
+		}
+		return nil, nil
+	}
+
+	if l.async != nil {
+		return l.async, entries
+	}
+
+	for _, e := range entries {
+		l.writeEntryLocked(e)
+	}
+	return nil, nil
+}
+
+// entryLocked renders a message at level and resolves its destinations.
+// Must be called under l.mu.
+func (l *Logger) entryLocked(level LogLevel, sourceInfo string, msg string) entry {
+	return entry{
+		level:     level,
+		line:      l.formatLine(level, sourceInfo, msg),
+		toConsole: l.consoleEnabledLocked(level),
+		toFile:    l.fileEnabledLocked(level),
+		toSinks:   l.sinksEnabled(level),
+	}
+}
+
+// writeEntryLocked writes a rendered line to its destinations.
+// Must be called under l.mu.
+func (l *Logger) writeEntryLocked(e entry) {
+	if l.recent != nil {
+		l.recent.add(e.line)
+	}
 
 	// Write to console
-	if (l.outputMode == ConsoleOnly || l.outputMode == Both) && level >= l.consoleLevel {
-		l.writeConsole(level, logLine)
+	if e.toConsole {
+		l.writeConsole(e.level, e.line)
+	}
+
+	// Write to file and the writers duplicating it
+	if e.toFile {
+		if !l.writeLevelFile(e.level, e.line) {
+			l.writeFile(e.line)
+		}
+		l.writeFileWriters(e.line)
+	}
+
+	// Queue to additional sinks
+	if e.toSinks {
+		l.writeSinks(e.level, e.line)
+	}
+}
+
+// consoleEnabledLocked reports whether a message at level goes to the console.
+// Must be called under l.mu.
+func (l *Logger) consoleEnabledLocked(level LogLevel) bool {
+	min := l.consoleLevel
+	if l.hasSourceLevel {
+		min = l.sourceLevel
+	}
+	return (l.outputMode == ConsoleOnly || l.outputMode == Both) && level >= min
+}
+
+// fileEnabledLocked reports whether a message at level goes to the file.
+// Must be called under l.mu.
+func (l *Logger) fileEnabledLocked(level LogLevel) bool {
+	min := l.fileLevel
+	if l.hasSourceLevel {
+		min = l.sourceLevel
+	}
+	return (l.outputMode == FileOnly || l.outputMode == Both) && level >= min
+}
+
+// IsConsoleEnabled reports whether the global logger writes a message at level to the console.
+// Returns false if the logger is not initialized.
+func IsConsoleEnabled(level LogLevel) bool {
+	l := getDefault()
+	return l != nil && l.IsConsoleEnabled(level)
+}
+
+// IsFileEnabled reports whether the global logger writes a message at level to the file.
+// Returns false if the logger is not initialized.
+func IsFileEnabled(level LogLevel) bool {
+	l := getDefault()
+	return l != nil && l.IsFileEnabled(level)
+}
+
+// IsEnabled reports whether the global logger writes a message at level anywhere.
+// Use it to skip building expensive messages. Returns false if the logger is not initialized.
+func IsEnabled(level LogLevel) bool {
+	l := getDefault()
+	return l != nil && l.IsEnabled(level)
+}
+
+// IsConsoleEnabled reports whether a message at level goes to the console
+// under the current output mode and console level.
+func (l *Logger) IsConsoleEnabled(level LogLevel) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.consoleEnabledLocked(level)
+}
+
+// IsFileEnabled reports whether a message at level goes to the file
+// under the current output mode and file level.
+func (l *Logger) IsFileEnabled(level LogLevel) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.fileEnabledLocked(level)
+}
+
+// IsEnabled reports whether a message at level would be written anywhere:
+// to the console, the file or one of the sinks, or from a file whose
+// per-file level allows it (see SetSourceLevels).
+func (l *Logger) IsEnabled(level LogLevel) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.consoleEnabledLocked(level) || l.fileEnabledLocked(level) || l.sinksEnabled(level) ||
+		l.caller.sourceEnabled(level)
+}
+
+// plan reports whether a message at level has to be processed at all
+// (it is written somewhere, checked in dry-run mode or may pass a per-file level)
+// and how its caller location should be looked up and rendered.
+func (l *Logger) plan(level LogLevel) (process bool, caller callerConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.processLocked(level) || l.caller.sourceEnabled(level), l.caller
+}
+
+// processLocked is plan without per-file levels: it reports whether a message
+// at level is written somewhere or checked in dry-run mode. Must be called under l.mu.
+func (l *Logger) processLocked(level LogLevel) bool {
+	return l.dryRun || l.consoleEnabledLocked(level) || l.fileEnabledLocked(level) || l.sinksEnabled(level)
+}
+
+// SetIncludeCaller enables or disables the caller lookup (file:line) of the global logger.
+// Does nothing if the logger is not initialized.
+func SetIncludeCaller(enabled bool) {
+	if l := getDefault(); l != nil {
+		l.SetIncludeCaller(enabled)
+	}
+}
+
+// SetIncludeCaller enables or disables the caller lookup (file:line) for each line.
+// The lookup is relatively expensive; when disabled the source is rendered as "???".
+// Enabled by default.
+func (l *Logger) SetIncludeCaller(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.caller.include = enabled
+}
+
+// Rotate forces the global logger to start a new timestamped file.
+// Returns nil if the logger is not initialized.
+func Rotate() error {
+	l := getDefault()
+	if l == nil {
+		return nil
+	}
+	return l.Rotate()
+}
+
+// Rotate starts a new timestamped file regardless of the size and line limits.
+// Lines queued in async mode are written to the old file first. Destinations
+// set by SetLevelFile are rotated as well.
+// It is a no-op in console-only mode and for custom writers.
+func (l *Logger) Rotate() error {
+	l.drainAsync()
+	defer l.notifyRotations()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.outputMode == ConsoleOnly {
+		return nil
+	}
+	for _, dest := range l.levelFiles {
+		if err := dest.Rotate(); err != nil {
+			return err
+		}
+	}
+	if l.externalWriter {
+		return nil
+	}
+	return l.rotateLocked()
+}
+
+// Reopen closes and reopens the log file of the global logger.
+// Returns nil if the logger is not initialized.
+func Reopen() error {
+	l := getDefault()
+	if l == nil {
+		return nil
+	}
+	return l.Reopen()
+}
+
+// Reopen closes the active log file and opens it again by name. Call it from
+// a SIGHUP handler after an external tool such as logrotate moved the file;
+// otherwise the logger keeps writing to the moved (or deleted) file.
+// Destinations set by SetLevelFile are reopened as well.
+// It is a no-op in console-only mode and for custom writers.
+func (l *Logger) Reopen() error {
+	l.drainAsync()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.outputMode == ConsoleOnly {
+		return nil
+	}
+	for _, dest := range l.levelFiles {
+		if err := dest.Reopen(); err != nil {
+			return err
+		}
+	}
+	if l.externalWriter {
+		return nil
+	}
+	if l.filePath == "" {
+		return l.openNewFileLocked()
 	}
 
-	// Write to file
-	if (l.outputMode == FileOnly || l.outputMode == Both) && level >= l.fileLevel {
-		l.writeFile(logLine)
+	file, err := os.OpenFile(l.filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, l.fileMode)
+	if err != nil {
+		l.lifecycleLocked("open_failed", Fields{"path": l.filePath, "error": err})
+		return err
 	}
+	l.flushBatchLocked()
+	if old, ok := l.fileWriter.(*os.File); ok && old != nil {
+		_ = l.closeFileHandleLocked(old)
+	}
+	l.fileWriter = file
+
+	if stat, err := file.Stat(); err == nil {
+		l.currentSize = stat.Size()
+	} else {
+		l.currentSize = 0
+	}
+	l.currentLines = 0
+
+	l.lifecycleLocked("reopen", Fields{"path": l.filePath, "size": l.currentSize})
+	return nil
 }
 
-// shouldRotate checks if log file rotation is needed based on file size.
+// CurrentFilePath returns the path of the log file the global logger writes to.
+// Returns an empty string if the logger is not initialized.
+func CurrentFilePath() string {
+	l := getDefault()
+	if l == nil {
+		return ""
+	}
+	return l.CurrentFilePath()
+}
+
+// CurrentFilePath returns the path of the active (timestamped) log file, e.g. to tail
+// or ship it. It is empty in console-only mode, for custom writers and before
+// the file is opened.
+func (l *Logger) CurrentFilePath() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.filePath
+}
+
+// OpenCurrentFile opens the active log file of the global logger for reading.
+// Returns ErrNotInitialized if the logger is not initialized.
+func OpenCurrentFile() (io.ReadCloser, error) {
+	l := getDefault()
+	if l == nil {
+		return nil, ErrNotInitialized
+	}
+	return l.OpenCurrentFile()
+}
+
+// OpenCurrentFile opens a read-only handle to the active log file, positioned at
+// its beginning, so a shipper can copy it without racing rotation: the pending
+// batch is written first and the file is opened under the lock, so the handle
+// refers to the file that was current at the time of the call. On Unix the
+// handle keeps the inode alive even if retention deletes the file mid-read.
+// The caller must close it. Returns ErrNoLogFile in console-only mode, for
+// custom writers and before the file is opened.
+func (l *Logger) OpenCurrentFile() (io.ReadCloser, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.filePath == "" || l.fileWriter == nil {
+		return nil, ErrNoLogFile
+	}
+	l.flushBatchLocked()
+	return os.Open(l.filePath)
+}
+
+// shouldRotate checks if log file rotation is needed based on file size or line count,
+// whichever limit is hit first. An empty file always takes the next line, so a line
+// larger than maxFileSize (or the first write after a lazy open) does not leave
+// an empty file behind.
 func (l *Logger) shouldRotate(nextBytes int64) bool {
+	if l.externalWriter || l.currentSize == 0 {
+		return false
+	}
+	if l.maxLines > 0 && l.currentLines >= l.maxLines {
+		return true
+	}
 	return l.maxFileSize > 0 && (l.currentSize+nextBytes) > l.maxFileSize
 }
 
 // rotateLocked closes current file and opens a new timestamp file.
 // Must be called under l.mu.
-// no file-count limit: old files are kept.
+// Old files are kept unless maxBackups is set.
 func (l *Logger) rotateLocked() error {
-	return l.openNewFileLocked()
+	oldPath := l.filePath
+	if err := l.openNewFileLocked(); err != nil {
+		return err
+	}
+	if oldPath != "" {
+		l.recordRotationLocked(oldPath, l.filePath)
+	}
+	l.pruneBackupsLocked()
+	return nil
 }
 
-// openNewFileLocked opens a new timestamp file based on l.basePath.
+// openNewFileLocked opens a new timestamp file based on l.basePath and closes the
+// current one. On error the current file (if any) is kept open and unchanged.
 // Must be called under l.mu.
 func (l *Logger) openNewFileLocked() error {
 	if l.basePath == "" {
 		return fmt.Errorf("log file path is empty")
 	}
 
-	if err := ensureDir(l.basePath); err != nil {
+	if err := ensureDir(l.basePath, l.dirMode); err != nil {
 		return err
 	}
 
-	path, err := uniqueLogPath(l.basePath)
+	path, err := uniqueLogPath(l.basePath, l.nowLocked(), l.collisionLimitLocked())
 	if err != nil {
+		l.lifecycleLocked("open_failed", Fields{"error": err})
 		return err
 	}
 
-	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, l.fileMode)
 	if err != nil {
+		l.lifecycleLocked("open_failed", Fields{"path": path, "error": err})
 		return err
 	}
 
-	// Close old file if any
+	if l.fileWriter != nil {
+		l.lifecycleLocked("rotate", Fields{"from": l.filePath, "to": path, "size": l.currentSize})
+	} else {
+		l.lifecycleLocked("open", Fields{"path": path})
+	}
+
+	// Close old file if any, after writing the pending batch to it
+	l.flushBatchLocked()
 	if old, ok := l.fileWriter.(*os.File); ok && old != nil {
-		_ = old.Close()
+		_ = l.closeFileHandleLocked(old)
 	}
 
 	l.fileWriter = file
@@ -270,22 +1276,30 @@ func (l *Logger) openNewFileLocked() error {
 	} else {
 		l.currentSize = 0
 	}
+	l.currentLines = 0
 
+	if l.symlinkCurrent {
+		l.updateSymlinkLocked()
+	}
 	return nil
 }
 
-// ensureDir creates directory for file path if needed.
-func ensureDir(path string) error {
+// ensureDir creates directory for file path with perm if needed.
+func ensureDir(path string, perm os.FileMode) error {
 	dir := filepath.Dir(path)
 	if dir == "." || dir == "" || dir == string(filepath.Separator) {
 		return nil
 	}
-	return os.MkdirAll(dir, 0755)
+	return os.MkdirAll(dir, perm)
 }
 
-// timestampSuffix returns a Windows safe timestamp with seconds.
-func timestampSuffix() string {
-	return time.Now().Format("02.01.2006_15-04-05.000")
+// timestampLayout is the layout of the timestamp in log file names.
+// It is Windows safe (no colons) and sortable only after parsing.
+const timestampLayout = "02.01.2006_15-04-05.000"
+
+// timestampSuffix returns a Windows safe timestamp of t with seconds.
+func timestampSuffix(t time.Time) string {
+	return t.Format(timestampLayout)
 }
 
 // pathWithSuffix inserts suffix before extension:
@@ -304,9 +1318,39 @@ func pathWithSuffix(basePath, suffix string) string {
 	return filepath.Join(dir, newBase)
 }
 
-// uniqueLogPath picks a unique timestamped file path. If collision occurs, adds _01, _02, ...
-func uniqueLogPath(basePath string) (string, error) {
-	suffix := timestampSuffix()
+// defaultCollisionLimit is the number of _NN suffixes tried by default.
+const defaultCollisionLimit = 16
+
+// SetCollisionLimit sets how many _NN suffixes the global logger tries for a taken
+// file name. Does nothing if the logger is not initialized.
+func SetCollisionLimit(n int) {
+	if l := getDefault(); l != nil {
+		l.SetCollisionLimit(n)
+	}
+}
+
+// SetCollisionLimit sets how many _01, _02, ... suffixes are tried when the timestamped
+// file name is taken (each costs a stat call). Beyond the limit a nanosecond plus random
+// suffix is used instead. n <= 0 restores the default of 16.
+func (l *Logger) SetCollisionLimit(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.collisionLimit = n
+}
+
+// collisionLimitLocked returns the effective collision limit.
+// Must be called under l.mu.
+func (l *Logger) collisionLimitLocked() int {
+	if l.collisionLimit <= 0 {
+		return defaultCollisionLimit
+	}
+	return l.collisionLimit
+}
+
+// uniqueLogPath picks a unique file path timestamped with now. If collision occurs, adds _01, _02, ...
+// up to limit; after that it falls back to a nanosecond plus random suffix.
+func uniqueLogPath(basePath string, now time.Time, limit int) (string, error) {
+	suffix := timestampSuffix(now)
 	candidatePath := pathWithSuffix(basePath, suffix)
 
 	_, statErr := os.Stat(candidatePath)
@@ -317,7 +1361,7 @@ func uniqueLogPath(basePath string) (string, error) {
 		return "", statErr
 	}
 
-	for i := 1; i <= 9999; i++ {
+	for i := 1; i <= limit; i++ {
 		nextSuffix := fmt.Sprintf("%s_%02d", suffix, i)
 		nextPath := pathWithSuffix(basePath, nextSuffix)
 
@@ -330,95 +1374,319 @@ func uniqueLogPath(basePath string) (string, error) {
 		}
 	}
 
-	msSUffix := time.Now().Format("02.01.2006_15-40-05.000")
-	return pathWithSuffix(basePath, msSUffix), nil
+	// Nanoseconds alone repeat under a coarse clock, the random part makes a clash negligible
+	for attempt := 0; attempt < 3; attempt++ {
+		nextSuffix := fmt.Sprintf("%s_%09d_%06x", suffix, now.Nanosecond(), rand.Uint32()&0xffffff)
+		nextPath := pathWithSuffix(basePath, nextSuffix)
+
+		_, statErr = os.Stat(nextPath)
+		if os.IsNotExist(statErr) {
+			return nextPath, nil
+		}
+		if statErr != nil {
+			return "", statErr
+		}
+	}
+	return "", fmt.Errorf("no unique log file name for %s", candidatePath)
 }
 
-// getConsoleWriter returns the appropriate console writer based on log level.
-// Errors are written to stderr, other levels to stdout.
-func getConsoleWriter(level LogLevel) io.Writer {
-	if level == LevelError {
-		return os.Stderr
+// SetConsoleOutput replaces the console writers of the global logger
+// (os.Stdout and os.Stderr by default). Does nothing if the logger is not initialized.
+func SetConsoleOutput(stdout, stderr io.Writer) {
+	if l := getDefault(); l != nil {
+		l.SetConsoleOutput(stdout, stderr)
+	}
+}
+
+// SetConsoleOutput replaces the console writers of this logger
+// (os.Stdout and os.Stderr by default), e.g. with buffers in tests.
+// A nil writer restores the corresponding default.
+func (l *Logger) SetConsoleOutput(stdout, stderr io.Writer) {
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stdout = stdout
+	l.stderr = stderr
+}
+
+// SetStderrLevel sets the lowest level the global logger writes to stderr.
+// Does nothing if the logger is not initialized.
+func SetStderrLevel(level LogLevel) {
+	if l := getDefault(); l != nil {
+		l.SetStderrLevel(level)
+	}
+}
+
+// SetStderrLevel sets the lowest level written to stderr instead of stdout
+// (LevelError by default), e.g. LevelWarn to keep warnings out of a pipeline
+// that consumes stdout. It does not change which lines reach the console.
+func (l *Logger) SetStderrLevel(level LogLevel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stderrLevel = level
+}
+
+// consoleWriterLocked returns the appropriate console writer based on log level.
+// Levels from stderrLevel up are written to stderr, lower levels to stdout.
+// Must be called under l.mu.
+func (l *Logger) consoleWriterLocked(level LogLevel) io.Writer {
+	if level >= l.stderrLevel {
+		return l.stderr
+	}
+	return l.stdout
+}
+
+// consoleWriters returns the stdout and stderr writers of l,
+// or os.Stdout and os.Stderr if l is nil.
+func (l *Logger) consoleWriters() (stdout, stderr io.Writer) {
+	if l == nil {
+		return os.Stdout, os.Stderr
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stdout, l.stderr
+}
+
+// consoleHelperOutputs reports whether a Console* helper prints to the console
+// and logs to file in the current output mode. l may be nil.
+func (l *Logger) consoleHelperOutputs() (console, file bool) {
+	if l == nil {
+		return true, false
+	}
+	mode := l.GetOutputMode()
+	return mode == ConsoleOnly || mode == Both, mode == FileOnly || mode == Both
+}
+
+// Trace logs a trace level message with formatting.
+// These messages are the most verbose and are written only when a threshold is LevelTrace.
+func Trace(format string, v ...interface{}) {
+	if l := getDefault(); l != nil {
+		l.log(LevelTrace, format, v...)
 	}
-	return os.Stdout
 }
 
 // Debug logs a debug level message with formatting.
 // These messages are typically used for detailed development information.
 func Debug(format string, v ...interface{}) {
-	if defaultLogger != nil {
-		defaultLogger.log(LevelDebug, "DEBUG", format, v...)
+	if l := getDefault(); l != nil {
+		l.log(LevelDebug, format, v...)
 	}
 }
 
 // Info logs an info level message with formatting.
 // These messages are used for general operational information.
 func Info(format string, v ...interface{}) {
-	if defaultLogger != nil {
-		defaultLogger.log(LevelInfo, "INFO", format, v...)
+	if l := getDefault(); l != nil {
+		l.log(LevelInfo, format, v...)
 	}
 }
 
 // Warn logs a warning level message with formatting.
 // These messages indicate potentially harmful situations.
 func Warn(format string, v ...interface{}) {
-	if defaultLogger != nil {
-		defaultLogger.log(LevelWarn, "WARN", format, v...)
+	if l := getDefault(); l != nil {
+		l.log(LevelWarn, format, v...)
 	}
 }
 
 // Error logs an error level message with formatting.
 // These messages indicate error conditions that might still allow the application to continue running.
 func Error(format string, v ...interface{}) {
-	if defaultLogger != nil {
-		defaultLogger.log(LevelError, "ERROR", format, v...)
+	if l := getDefault(); l != nil {
+		l.log(LevelError, format, v...)
 	}
 }
 
+// Trace logs a trace level message with formatting to this logger.
+func (l *Logger) Trace(format string, v ...interface{}) {
+	l.log(LevelTrace, format, v...)
+}
+
+// Debug logs a debug level message with formatting to this logger.
+func (l *Logger) Debug(format string, v ...interface{}) {
+	l.log(LevelDebug, format, v...)
+}
+
+// Info logs an info level message with formatting to this logger.
+func (l *Logger) Info(format string, v ...interface{}) {
+	l.log(LevelInfo, format, v...)
+}
+
+// Warn logs a warning level message with formatting to this logger.
+func (l *Logger) Warn(format string, v ...interface{}) {
+	l.log(LevelWarn, format, v...)
+}
+
+// Error logs an error level message with formatting to this logger.
+func (l *Logger) Error(format string, v ...interface{}) {
+	l.log(LevelError, format, v...)
+}
+
+// Log logs a message with formatting at a level computed at runtime,
+// e.g. from an HTTP status code.
+func Log(level LogLevel, format string, v ...interface{}) {
+	if l := getDefault(); l != nil {
+		l.log(level, format, v...)
+	}
+}
+
+// Log logs a message with formatting at level to this logger.
+func (l *Logger) Log(level LogLevel, format string, v ...interface{}) {
+	l.log(level, format, v...)
+}
+
+// LogString logs a message with formatting at level to the global logger and returns
+// the formatted message, e.g. to reuse it in an error. The message is returned even if
+// the logger is not initialized or the level is filtered out.
+func LogString(level LogLevel, format string, v ...interface{}) string {
+	msg := fmt.Sprintf(format, v...)
+	if l := getDefault(); l != nil {
+		l.log(level, "%s", msg)
+	}
+	return msg
+}
+
+// LogString logs a message with formatting at level to this logger and returns
+// the formatted message (without timestamp, level and source), even if the level
+// is filtered out.
+func (l *Logger) LogString(level LogLevel, format string, v ...interface{}) string {
+	msg := fmt.Sprintf(format, v...)
+	if l != nil {
+		l.log(level, "%s", msg)
+	}
+	return msg
+}
+
+// ErrorString logs an error level message with formatting to the global logger
+// and returns the formatted message:
+//
+//	return errors.New(logger.ErrorString("load config %s: %v", path, err))
+func ErrorString(format string, v ...interface{}) string {
+	msg := fmt.Sprintf(format, v...)
+	if l := getDefault(); l != nil {
+		l.log(LevelError, "%s", msg)
+	}
+	return msg
+}
+
+// ErrorString logs an error level message with formatting to this logger
+// and returns the formatted message.
+func (l *Logger) ErrorString(format string, v ...interface{}) string {
+	msg := fmt.Sprintf(format, v...)
+	if l != nil {
+		l.log(LevelError, "%s", msg)
+	}
+	return msg
+}
+
+// Panic logs an error level message with formatting to the global logger, flushes it
+// and panics with the formatted message. It panics even if the logger is not initialized.
+func Panic(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	if l := getDefault(); l != nil {
+		l.log(LevelError, "%s", msg)
+		_ = l.Flush()
+	}
+	panic(msg)
+}
+
+// Panic logs an error level message with formatting, flushes the logger (see Flush)
+// so the line is not lost if nothing recovers, and then calls panic with the formatted
+// message. Unlike os.Exit, deferred functions and recover handlers still run.
+func (l *Logger) Panic(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	if l != nil {
+		l.log(LevelError, "%s", msg)
+		_ = l.Flush()
+	}
+	panic(msg)
+}
+
 // ConsoleError displays an error message to the user in the console.
 // Always shows in console (regardless of log level) and also logs to file if configured.
-// Formats the message with emoji for better visibility.
+// The message is labeled "Error:", with ❌ on a terminal when SetEmoji is enabled.
 func ConsoleError(format string, v ...interface{}) {
+	l := getDefault()
 	msg := fmt.Sprintf(format, v...)
+	console, file := l.consoleHelperOutputs()
 
 	// Always show error to user in console
-	if defaultLogger == nil || defaultLogger.outputMode == ConsoleOnly || defaultLogger.outputMode == Both {
-		fmt.Fprintln(os.Stderr, "Error:", msg)
+	if console {
+		_, stderr := l.consoleWriters()
+		fmt.Fprintln(stderr, l.consoleLabel(stderr, "❌", "Error:"), msg)
 	}
 
 	// Log to file if needed
-	if defaultLogger != nil && (defaultLogger.outputMode == FileOnly || defaultLogger.outputMode == Both) {
-		defaultLogger.log(LevelError, "ERROR", format, v...)
+	if file {
+		l.log(LevelError, format, v...)
+	}
+}
+
+// ConsoleWarn displays a warning message to the user in the console.
+// Always shows in console on stderr (regardless of log level) and also logs
+// to file at LevelWarn if configured.
+// The message is labeled "Warning:", with ⚠️ on a terminal when SetEmoji is enabled.
+func ConsoleWarn(format string, v ...interface{}) {
+	l := getDefault()
+	msg := fmt.Sprintf(format, v...)
+	console, file := l.consoleHelperOutputs()
+
+	if console {
+		_, stderr := l.consoleWriters()
+		fmt.Fprintln(stderr, l.consoleLabel(stderr, "⚠️", "Warning:"), msg)
+	}
+
+	if file {
+		l.log(LevelWarn, format, v...)
 	}
 }
 
 // ConsoleInfo displays an informational message to the user in the console.
-// Always shows in console and also logs to file if configured.
-// Formats the message with emoji for better visibility.
+// Always shows in console (unless SetConsoleHelpersRespectLevel is enabled)
+// and also logs to file if configured.
+// The message is labeled "Info:", with ℹ️ on a terminal when SetEmoji is enabled.
 func ConsoleInfo(format string, v ...interface{}) {
+	l := getDefault()
 	msg := fmt.Sprintf(format, v...)
+	console, file := l.consoleHelperOutputs()
 
-	if defaultLogger == nil || defaultLogger.outputMode == ConsoleOnly || defaultLogger.outputMode == Both {
-		fmt.Println("Info:", msg)
+	if console {
+		stdout, _ := l.consoleWriters()
+		if l.consoleHelperVisible(LevelInfo) {
+			fmt.Fprintln(stdout, l.consoleLabel(stdout, "ℹ️", "Info:"), msg)
+		}
 	}
 
-	if defaultLogger != nil && (defaultLogger.outputMode == FileOnly || defaultLogger.outputMode == Both) {
-		defaultLogger.log(LevelInfo, "INFO", format, v...)
+	if file {
+		l.log(LevelInfo, format, v...)
 	}
 }
 
 // ConsoleSuccess displays a success message to the user in the console.
-// Always shows in console and also logs to file if configured.
-// Formats the message with emoji for better visibility.
+// Always shows in console (unless SetConsoleHelpersRespectLevel is enabled)
+// and also logs to file if configured.
+// The message is labeled "Success:", with ✅ on a terminal when SetEmoji is enabled.
 func ConsoleSuccess(format string, v ...interface{}) {
+	l := getDefault()
 	msg := fmt.Sprintf(format, v...)
+	console, file := l.consoleHelperOutputs()
 
-	if defaultLogger == nil || defaultLogger.outputMode == ConsoleOnly || defaultLogger.outputMode == Both {
-		fmt.Println("Success:", msg)
+	if console {
+		stdout, _ := l.consoleWriters()
+		if l.consoleHelperVisible(LevelInfo) {
+			fmt.Fprintln(stdout, l.consoleLabel(stdout, "✅", "Success:"), msg)
+		}
 	}
 
-	if defaultLogger != nil && (defaultLogger.outputMode == FileOnly || defaultLogger.outputMode == Both) {
-		defaultLogger.log(LevelInfo, "INFO", format, v...)
+	if file {
+		l.log(LevelInfo, format, v...)
 	}
 }
 
@@ -426,8 +1694,11 @@ func ConsoleSuccess(format string, v ...interface{}) {
 // Only shows in console, never logs to file.
 // Use for command usage information and help text.
 func ConsoleHelp(message string) {
-	if defaultLogger == nil || defaultLogger.outputMode == ConsoleOnly || defaultLogger.outputMode == Both {
-		fmt.Println(message)
+	l := getDefault()
+	console, _ := l.consoleHelperOutputs()
+	if console {
+		stdout, _ := l.consoleWriters()
+		fmt.Fprintln(stdout, message)
 	}
 }
 
@@ -435,8 +1706,11 @@ func ConsoleHelp(message string) {
 // Only shows in console, never logs to file.
 // Use for formatted command usage information and help text.
 func ConsoleHelpf(format string, v ...interface{}) {
+	l := getDefault()
 	msg := fmt.Sprintf(format, v...)
-	if defaultLogger == nil || defaultLogger.outputMode == ConsoleOnly || defaultLogger.outputMode == Both {
-		fmt.Println(msg)
+	console, _ := l.consoleHelperOutputs()
+	if console {
+		stdout, _ := l.consoleWriters()
+		fmt.Fprintln(stdout, msg)
 	}
 }
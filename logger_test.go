@@ -0,0 +1,3319 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestLogger returns a console-only logger writing stdout and stderr to one buffer.
+func newTestLogger(t testing.TB, opts ...Option) (*Logger, *bytes.Buffer) {
+	t.Helper()
+	l, err := NewWithOptions(opts...)
+	if err != nil {
+		t.Fatalf("NewWithOptions: %v", err)
+	}
+	buf := &bytes.Buffer{}
+	l.SetConsoleOutput(buf, buf)
+	return l, buf
+}
+
+func TestSliceFieldRendering(t *testing.T) {
+	for _, tt := range []struct {
+		value interface{}
+		sep   string
+		want  string
+	}{
+		{[]string{"a", "b", "c"}, ",", "a,b,c"},
+		{[]int{1, 2}, ";", "1;2"},
+		{[2]string{"x", "y"}, " | ", "x | y"},
+		{[]byte("raw"), ",", "raw"},
+		{[]string{}, ",", ""},
+		{42, ",", "42"},
+		{nil, ",", "<nil>"},
+	} {
+		if got := formatFieldValue(tt.value, tt.sep); got != tt.want {
+			t.Errorf("formatFieldValue(%#v, %q) = %q, want %q", tt.value, tt.sep, got, tt.want)
+		}
+	}
+
+	dir := t.TempDir()
+	l := newFileLogger(t, dir)
+	l.WithFields(Fields{"ids": []string{"a", "b", "c"}}).Info("affected")
+	l.SetSliceFieldSeparator(";")
+	l.WithFields(Fields{"ids": []string{"a", "b", "c"}}).Info("affected")
+	if got := readLog(t, dir); !strings.Contains(got, " ids=a,b,c\n") || !strings.Contains(got, " ids=a;b;c\n") {
+		t.Errorf("lines = %q, want ids=a,b,c then ids=a;b;c", got)
+	}
+}
+
+// resetGlobal tears down the global logger when the test finishes.
+func resetGlobal(t *testing.T) {
+	t.Helper()
+	_ = Reset()
+	t.Cleanup(func() { _ = Reset() })
+}
+
+// readFile returns the content of path, failing the test on error.
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestResetAllowsReinit(t *testing.T) {
+	resetGlobal(t)
+	first, second := t.TempDir(), t.TempDir()
+	if err := Init(FileOnly, LevelDebug, LevelDebug, filepath.Join(first, "app.log"), 0); err != nil {
+		t.Fatal(err)
+	}
+	Info("to first")
+
+	// Log calls racing with Reset must not panic
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			Info("racing %d", i)
+		}
+	}()
+	if err := Reset(); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	wg.Wait()
+	Info("discarded")
+
+	if err := Init(FileOnly, LevelDebug, LevelDebug, filepath.Join(second, "app.log"), 0); err != nil {
+		t.Fatalf("Init after Reset: %v", err)
+	}
+	Info("to second")
+	if err := Reset(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct{ dir, want, unwanted string }{
+		{first, "to first", "to second"},
+		{second, "to second", "discarded"},
+	} {
+		paths, err := filepath.Glob(filepath.Join(tt.dir, "app*.log"))
+		if err != nil || len(paths) != 1 {
+			t.Fatalf("log files in %s: %v (%v)", tt.dir, paths, err)
+		}
+		if got := readFile(t, paths[0]); !strings.Contains(got, tt.want) || strings.Contains(got, tt.unwanted) {
+			t.Errorf("%s = %q", paths[0], got)
+		}
+	}
+}
+
+func TestCapture(t *testing.T) {
+	dir := t.TempDir()
+	l := newFileLogger(t, dir)
+
+	lines, err := l.Capture(func() {
+		l.Info("first")
+		l.Warn("second %d", 2)
+		l.Debug("third")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("captured %d lines, want 3: %q", len(lines), lines)
+	}
+	for i, want := range []string{"INFO: ", "WARN: ", "DEBUG: "} {
+		if !strings.Contains(lines[i], want) {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want)
+		}
+	}
+	if !strings.HasSuffix(lines[1], " - second 2") {
+		t.Errorf("line 1 = %q", lines[1])
+	}
+	if got := readLog(t, dir); got != "" {
+		t.Errorf("file received %q during capture", got)
+	}
+
+	l.Info("after")
+	if got := readLog(t, dir); !strings.HasSuffix(got, " - after\n") {
+		t.Errorf("file output not restored: %q", got)
+	}
+
+	resetGlobal(t)
+	if _, err := Capture(func() {}); !errors.Is(err, ErrNotInitialized) {
+		t.Errorf("Capture without a logger: %v, want ErrNotInitialized", err)
+	}
+}
+
+// newFileLogger returns a file-only logger writing all levels under dir and
+// closes it when the test finishes.
+func newFileLogger(t *testing.T, dir string) *Logger {
+	t.Helper()
+	l, err := New(FileOnly, LevelDebug, LevelDebug, filepath.Join(dir, "app.log"), 0)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	return l
+}
+
+// readLog returns the content of the only log file in dir.
+func readLog(t *testing.T, dir string) string {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(dir, "app*.log"))
+	if err != nil || len(paths) != 1 {
+		t.Fatalf("log files in %s: %v (%v)", dir, paths, err)
+	}
+	return readFile(t, paths[0])
+}
+
+func TestJSONFormat(t *testing.T) {
+	dir := t.TempDir()
+	l := newFileLogger(t, dir)
+	l.SetFormat(FormatJSON)
+
+	l.Info("quote \" and\nnewline")
+	line := readLog(t, dir)
+	var rec map[string]string
+	if err := json.Unmarshal([]byte(line), &rec); err != nil {
+		t.Fatalf("line %q does not parse: %v", line, err)
+	}
+	if rec["level"] != "INFO" || rec["msg"] != "quote \" and\nnewline" {
+		t.Errorf("record = %v", rec)
+	}
+	if !strings.HasPrefix(rec["source"], "logger_test.go:") {
+		t.Errorf("source = %q", rec["source"])
+	}
+	if _, err := time.Parse(time.RFC3339, rec["time"]); err != nil {
+		t.Errorf("time %q is not RFC3339: %v", rec["time"], err)
+	}
+}
+
+// logFiles returns the paths of the regular files in dir sorted by name.
+func logFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			paths = append(paths, filepath.Join(dir, e.Name()))
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestMaxLinesRotation(t *testing.T) {
+	dir := t.TempDir()
+	l := newFileLogger(t, dir)
+	l.SetMaxLines(10)
+
+	for i := 1; i <= 11; i++ {
+		l.Info("line %d", i)
+	}
+
+	files := logFiles(t, dir)
+	if len(files) != 2 {
+		t.Fatalf("files = %v, want 2", files)
+	}
+	var counts []int
+	for _, path := range files {
+		got := readFile(t, path)
+		counts = append(counts, strings.Count(got, "\n"))
+		if strings.Count(got, "\n") == 1 && !strings.HasSuffix(got, " - line 11\n") {
+			t.Errorf("new file = %q, want line 11", got)
+		}
+	}
+	sort.Ints(counts)
+	if counts[0] != 1 || counts[1] != 10 {
+		t.Errorf("lines per file = %v, want 10 then 1", counts)
+	}
+}
+
+func TestRecoverAndLog(t *testing.T) {
+	dir := t.TempDir()
+	l := newFileLogger(t, dir)
+
+	var recovered interface{}
+	func() {
+		defer func() { recovered = recover() }()
+		func() {
+			defer l.RecoverAndLog()
+			panic("boom")
+		}()
+	}()
+
+	if recovered != "boom" {
+		t.Fatalf("re-panicked with %v, want boom", recovered)
+	}
+	out := readLog(t, dir)
+	if !strings.Contains(out, "ERROR: logger_test.go:") || !strings.Contains(out, " - panic: boom\n") {
+		t.Errorf("panic line missing or attributed to the logger:\n%s", out)
+	}
+	if !strings.Contains(out, "TestRecoverAndLog") {
+		t.Errorf("stack trace missing:\n%s", out)
+	}
+}
+
+func TestWithFields(t *testing.T) {
+	dir := t.TempDir()
+	l := newFileLogger(t, dir)
+
+	fields := Fields{"req": 42}
+	child := l.WithFields(fields)
+	fields["req"] = 43 // the child keeps its own copy
+
+	child.Info("done")
+	l.Info("parent")
+	lines := strings.Split(readLog(t, dir), "\n")
+	if !strings.HasSuffix(lines[0], " - done req=42") {
+		t.Errorf("text line = %q", lines[0])
+	}
+	if strings.Contains(lines[1], "req=") {
+		t.Errorf("parent got child fields: %q", lines[1])
+	}
+
+	jsonDir := t.TempDir()
+	j := newFileLogger(t, jsonDir)
+	j.SetFormat(FormatJSON)
+	j.WithFields(Fields{"req": 42}).WithFields(Fields{"user": "ann"}).Info("done")
+	var rec map[string]interface{}
+	if err := json.Unmarshal([]byte(readLog(t, jsonDir)), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["req"] != float64(42) || rec["user"] != "ann" {
+		t.Errorf("JSON record = %v", rec)
+	}
+
+	// Concurrent WithFields calls on a shared parent are safe
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			child.WithFields(Fields{"i": i})
+		}(i)
+	}
+	wg.Wait()
+}
+
+// testClock is a manually advanced clock installed with setClock.
+// It starts in the future, so files created by it are newer than files
+// created with the real clock before it was installed.
+type testClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func newTestClock() *testClock {
+	return &testClock{t: time.Date(2036, time.February, 2, 23, 10, 15, 0, time.UTC)}
+}
+
+func (c *testClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *testClock) add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func TestMaxBackups(t *testing.T) {
+	dir := t.TempDir()
+	l := newFileLogger(t, dir)
+	l.SetMaxLines(1) // every line after the first starts a new file
+	l.SetMaxBackups(2)
+
+	for i := 0; i < 6; i++ {
+		l.Info("line %d", i)
+		time.Sleep(2 * time.Millisecond) // distinct file timestamps
+	}
+
+	files := logFiles(t, dir)
+	if len(files) != 3 {
+		t.Fatalf("files = %v, want the active file and 2 backups", files)
+	}
+	var kept []string
+	for _, path := range files {
+		got := readFile(t, path)
+		kept = append(kept, got[strings.LastIndex(got, " - ")+3:len(got)-1])
+	}
+	sort.Strings(kept)
+	if strings.Join(kept, ",") != "line 3,line 4,line 5" {
+		t.Errorf("kept %v, want the newest three files", kept)
+	}
+}
+
+func TestInitTwice(t *testing.T) {
+	resetGlobal(t)
+	if err := InitConsoleOnly(LevelInfo); err != nil {
+		t.Fatal(err)
+	}
+	err := InitBoth(LevelInfo, LevelDebug, filepath.Join(t.TempDir(), "app.log"), 0)
+	if !errors.Is(err, ErrAlreadyInitialized) {
+		t.Fatalf("second Init = %v, want ErrAlreadyInitialized", err)
+	}
+	if GetConsoleLevel() != LevelInfo {
+		t.Errorf("second Init changed the console level to %v", GetConsoleLevel())
+	}
+
+	MustReinit(ConsoleOnly, LevelWarn, LevelDebug, "", 0)
+	if GetConsoleLevel() != LevelWarn {
+		t.Errorf("MustReinit did not apply: console level %v", GetConsoleLevel())
+	}
+}
+
+func TestCorrelationID(t *testing.T) {
+	l, buf := newTestLogger(t)
+
+	a, b := l.WithNewCorrelationID(), l.WithNewCorrelationID()
+	idA, idB := a.fields[CorrelationIDField], b.fields[CorrelationIDField]
+	if idA == idB {
+		t.Errorf("IDs are not unique: %v", idA)
+	}
+	a.Info("step")
+	if !strings.Contains(buf.String(), " correlation_id="+idA.(string)) {
+		t.Errorf("ID not attached: %q", buf)
+	}
+
+	defer func(src io.Reader) { idSource = src }(idSource)
+	idSource = rand.New(rand.NewSource(1))
+	first := NewCorrelationID()
+	idSource = rand.New(rand.NewSource(1))
+	if second := NewCorrelationID(); second != first || len(first) != correlationIDLength {
+		t.Errorf("seeded IDs %q and %q differ", first, second)
+	}
+}
+
+func TestMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "app.log")
+	now := time.Now()
+
+	old := pathWithSuffix(base, now.Add(-48*time.Hour).Format(timestampLayout))
+	recent := pathWithSuffix(base, now.Add(-2*time.Hour).Format(timestampLayout))
+	foreign := filepath.Join(dir, "app_not-a-timestamp.log")
+	for _, path := range []string{old, recent, foreign} {
+		if err := os.WriteFile(path, []byte("x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l := newFileLogger(t, dir)
+	l.SetMaxAge(24 * time.Hour)
+	l.SetMaxLines(1)
+	l.Info("first")
+	l.Info("second") // rotates and prunes
+
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("file older than maxAge survived: %v", err)
+	}
+	for _, path := range []string{recent, foreign} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s was removed: %v", filepath.Base(path), err)
+		}
+	}
+}
+
+func TestSetTimeFormatValidation(t *testing.T) {
+	l, buf := newTestLogger(t)
+
+	for _, layout := range []string{"2006/01/02 15:04:04", "no components"} {
+		if err := l.SetTimeFormat(layout); err == nil {
+			t.Errorf("SetTimeFormat(%q) accepted a broken layout", layout)
+		}
+	}
+	for _, layout := range []string{"", time.RFC3339, "2006-01-02 15:04:05.000", TimeFormatUnix} {
+		if err := l.SetTimeFormat(layout); err != nil {
+			t.Errorf("SetTimeFormat(%q) = %v", layout, err)
+		}
+	}
+
+	// A rejected layout keeps the current one
+	if err := l.SetTimeFormat("2006-01-02T15:04"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.SetTimeFormat("15:04:04"); err == nil {
+		t.Fatal("15:04:04 accepted")
+	}
+	l.SetUTC(true)
+	l.setClock(newTestClock().now)
+	l.Info("x")
+	if !strings.HasPrefix(buf.String(), "2036-02-02T23:10 INFO: ") {
+		t.Errorf("line = %q", buf)
+	}
+}
+
+func TestGCPFormat(t *testing.T) {
+	dir := t.TempDir()
+	l := newFileLogger(t, dir)
+	l.SetFormat(FormatGCP)
+	l.SetIncludeFunction(true)
+	l.Warn("disk almost full")
+	var rec map[string]interface{}
+	if err := json.Unmarshal([]byte(readLog(t, dir)), &rec); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if rec["severity"] != "WARNING" || rec["message"] != "disk almost full" {
+		t.Errorf("record = %v", rec)
+	}
+	loc, ok := rec[gcpSourceLocationKey].(map[string]interface{})
+	if !ok {
+		t.Fatalf("no %s in %v", gcpSourceLocationKey, rec)
+	}
+	if loc["file"] != "logger_test.go" || loc["line"] == "" || !strings.HasSuffix(loc["function"].(string), "TestGCPFormat") {
+		t.Errorf("sourceLocation = %v", loc)
+	}
+
+	for level, want := range map[LogLevel]string{LevelTrace: "DEBUG", LevelDebug: "DEBUG", LevelInfo: "INFO", LevelError: "ERROR"} {
+		if got := gcpSeverity(level); got != want {
+			t.Errorf("gcpSeverity(%v) = %s, want %s", level, got, want)
+		}
+	}
+}
+
+func TestInjectedClock(t *testing.T) {
+	dir := t.TempDir()
+	clock := newTestClock()
+	l := newFileLogger(t, dir)
+	l.setClock(clock.now)
+	l.SetMaxLines(1)
+
+	l.Info("tick")
+	if got := readLog(t, dir); !strings.HasPrefix(got, "2036/02/02 23:10:15 INFO: ") {
+		t.Errorf("line = %q", got)
+	}
+
+	clock.add(90 * time.Second)
+	l.Info("tock") // rotates
+	if _, err := os.Stat(filepath.Join(dir, "app_02.02.2036_23-11-45.000.log")); err != nil {
+		t.Errorf("rotated file not named after the clock: %v", err)
+	}
+}
+
+// blockingWriter blocks every Write until release is closed.
+type blockingWriter struct {
+	release chan struct{}
+	lines   atomic.Int64
+}
+
+func newBlockingWriter() *blockingWriter {
+	return &blockingWriter{release: make(chan struct{})}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.lines.Add(1)
+	return len(p), nil
+}
+
+func TestSlowSinkDoesNotBlock(t *testing.T) {
+	dir := t.TempDir()
+	l := newFileLogger(t, dir)
+	slow := newBlockingWriter()
+	sink := l.AddSink(slow, LevelDebug, 1, OverflowDrop)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			l.Info("line %d", i)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("logging blocked on a stalled sink")
+	}
+
+	if n := strings.Count(readLog(t, dir), "\n"); n != 100 {
+		t.Errorf("file got %d lines, want 100", n)
+	}
+	if sink.Dropped() == 0 {
+		t.Error("slow sink dropped nothing")
+	}
+
+	close(slow.release)
+	l.RemoveSink(sink)
+	if got := uint64(slow.lines.Load()) + sink.Dropped(); got != 100 {
+		t.Errorf("sink wrote+dropped %d lines, want 100", got)
+	}
+}
+
+func TestDiagnosticDump(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New(FileOnly, LevelWarn, LevelDebug, filepath.Join(dir, "app.log"), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.SetMaxBackups(3)
+	l.SetFormat(FormatJSON)
+	l.Error("boom")
+
+	files := logFiles(t, dir)
+	if len(files) != 1 {
+		t.Fatalf("files = %v", files)
+	}
+	dump := l.DiagnosticDump()
+	for _, want := range []string{
+		"output mode:     FileOnly",
+		"console level:   WARN",
+		"file level:      DEBUG",
+		"format:          JSON",
+		"color mode:      Never",
+		"console helpers: respect level=false",
+		"active file:     " + fmt.Sprintf("%q", files[0]),
+		"max file size:   1048576 bytes",
+		"max backups:     3",
+		"sinks:           0",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("dump lacks %q:\n%s", want, dump)
+		}
+	}
+}
+
+func TestSettingStrings(t *testing.T) {
+	for _, tt := range []struct {
+		value fmt.Stringer
+		want  string
+	}{
+		{FormatText, "Text"},
+		{FormatLogfmt, "Logfmt"},
+		{Format(9), "Format(9)"},
+		{ColorAuto, "Auto"},
+		{ColorMode(9), "ColorMode(9)"},
+		{OverflowBlock, "Block"},
+		{OverflowPolicy(9), "OverflowPolicy(9)"},
+	} {
+		if got := tt.value.String(); got != tt.want {
+			t.Errorf("%#v.String() = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+// closeRecorder is a buffer that records whether Close was called.
+type closeRecorder struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (w *closeRecorder) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestCustomWriter(t *testing.T) {
+	w := &closeRecorder{}
+	l, err := NewWithWriter(FileOnly, LevelDebug, LevelInfo, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.SetMaxLines(1) // rotation never applies to a custom writer
+
+	l.Debug("filtered")
+	l.Info("first")
+	l.Warn("second")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(w.String(), "\n"), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "INFO: logger_test.go:") || !strings.HasSuffix(lines[1], " - second") {
+		t.Errorf("lines = %q", lines)
+	}
+	if w.closed {
+		t.Error("Close closed the caller's writer")
+	}
+}
+
+func TestStdlibWriter(t *testing.T) {
+	dir := t.TempDir()
+	l := newFileLogger(t, dir)
+
+	std := log.New(l.Writer(LevelWarn), "", 0)
+	std.Printf("from %s", "stdlib")
+	std.Print("second")
+
+	got := readLog(t, dir)
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("file = %q, want 2 lines without blank ones", got)
+	}
+	if !strings.Contains(lines[0], "WARN: ") || !strings.HasSuffix(lines[0], " - from stdlib") {
+		t.Errorf("line = %q", lines[0])
+	}
+}
+
+func TestResetSampling(t *testing.T) {
+	dir := t.TempDir()
+	l := newFileLogger(t, dir)
+	l.SetRateLimit(LevelInfo, 1, time.Hour)
+	l.SetRateLimit(LevelWarn, 1, time.Hour)
+
+	l.Info("a")
+	l.Info("b") // suppressed
+	l.Warn("c")
+	l.Warn("d") // suppressed
+
+	l.ResetSamplingLevel(LevelInfo)
+	l.Info("e")
+	l.Warn("f") // still suppressed
+
+	l.ResetSampling()
+	l.Warn("g")
+
+	out := readLog(t, dir)
+	for _, msg := range []string{" - a\n", " - c\n", " - e\n", " - g\n", "... 1 messages suppressed", "... 2 messages suppressed"} {
+		if !strings.Contains(out, msg) {
+			t.Errorf("output lacks %q:\n%s", msg, out)
+		}
+	}
+	for _, msg := range []string{" - b\n", " - d\n", " - f\n"} {
+		if strings.Contains(out, msg) {
+			t.Errorf("output has suppressed %q:\n%s", msg, out)
+		}
+	}
+}
+
+func TestLifecycleSink(t *testing.T) {
+	dir := t.TempDir()
+	clock := newTestClock()
+	l := newFileLogger(t, dir)
+	l.SetMaxLines(1)
+	l.setClock(clock.now)
+
+	var events bytes.Buffer
+	l.SetLifecycleSink(&events)
+	l.Info("first")
+	clock.add(time.Second)
+	l.Info("second") // rotates
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, line := range strings.Split(strings.TrimSuffix(events.String(), "\n"), "\n") {
+		var ev map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("invalid event %q: %v", line, err)
+		}
+		if _, ok := ev["time"]; !ok {
+			t.Errorf("event without time: %q", line)
+		}
+		names = append(names, fmt.Sprint(ev["event"]))
+	}
+	if got := strings.Join(names, ","); got != "attach,rotate,close" {
+		t.Errorf("events = %s, want attach,rotate,close\n%s", got, events.String())
+	}
+}
+
+func TestSlogHandler(t *testing.T) {
+	dir := t.TempDir()
+	l := newFileLogger(t, dir)
+	slogger := slog.New(NewSlogHandler(l)).With("svc", "api")
+
+	slogger.Debug("debug", "n", 1)
+	slogger.WithGroup("req").Info("handled", "id", 7, slog.Group("user", "name", "ann"))
+	out := readLog(t, dir)
+	if !strings.Contains(out, "DEBUG: ") || !strings.Contains(out, " - debug n=1 svc=api\n") {
+		t.Errorf("debug line = %q", out)
+	}
+	if !strings.Contains(out, " - handled req.id=7 req.user.name=ann svc=api\n") {
+		t.Errorf("grouped line = %q", out)
+	}
+	if !strings.Contains(out, "logger_test.go:") {
+		t.Errorf("source does not point at the slog caller: %q", out)
+	}
+
+	l.SetFormat(FormatJSON)
+	slogger.Warn("json", "ok", true)
+	lines := strings.Split(strings.TrimSuffix(readLog(t, dir), "\n"), "\n")
+	var rec map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["level"] != "WARN" || rec["msg"] != "json" || rec["ok"] != true || rec["svc"] != "api" {
+		t.Errorf("JSON record = %v", rec)
+	}
+
+	l.SetFileLevel(LevelWarn)
+	if slogger.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("Enabled(Info) = true below the file level")
+	}
+}
+
+func TestColorOutput(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New(Both, LevelDebug, LevelDebug, filepath.Join(dir, "app.log"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	buf := &bytes.Buffer{}
+	l.SetConsoleOutput(buf, buf)
+	l.SetColorMode(ColorAlways)
+
+	l.Info("colored")
+	l.Error("failed")
+
+	out := buf.String()
+	if !strings.Contains(out, ansiGreen+"INFO"+ansiReset+":") {
+		t.Errorf("INFO is not green: %q", out)
+	}
+	if !strings.Contains(out, ansiRed+"ERROR"+ansiReset+":") {
+		t.Errorf("ERROR is not red: %q", out)
+	}
+	if file := readLog(t, dir); strings.Contains(file, "\x1b[") {
+		t.Errorf("file output is colorized: %q", file)
+	}
+
+	buf.Reset()
+	t.Setenv("FORCE_COLOR", "")
+	l.SetColorMode(ColorAuto) // a buffer is not a terminal
+	l.Info("plain")
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Errorf("ColorAuto colorized a buffer: %q", buf)
+	}
+}
+
+func TestMaxMessageLength(t *testing.T) {
+	l, buf := newTestLogger(t)
+	l.SetMaxMessageLength(LevelError, 6)
+	l.SetMaxMessageLength(LevelDebug, 20)
+
+	long := strings.Repeat("ж", 30)
+	l.Error("%s", long)
+	l.Debug("%s", long)
+	l.Info("%s", long) // no limit
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []string{strings.Repeat("ж", 5) + "…", strings.Repeat("ж", 19) + "…", long}
+	if len(lines) != len(want) {
+		t.Fatalf("lines = %q", lines)
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, " - "+want[i]) {
+			t.Errorf("line %d = %q, want message %q", i, line, want[i])
+		}
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New(Both, LevelDebug, LevelDebug, filepath.Join(dir, "app.log"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	buf := &bytes.Buffer{}
+	l.SetConsoleOutput(buf, buf)
+
+	l.SetDryRun(true)
+	// Formats are variables so that go vet does not reject the mismatches
+	countFormat, missingFormat := "count %d", "missing %s %s"
+	l.Info(countFormat, "x")
+	l.Info("ok %d", 1)
+	l.Warn(missingFormat, "a")
+
+	issues := l.FormatIssues()
+	if len(issues) != 2 {
+		t.Fatalf("issues = %q, want 2", issues)
+	}
+	if !strings.HasPrefix(issues[0], "logger_test.go:") || !strings.Contains(issues[0], `format "count %d" produced "count %!d(string=x)"`) {
+		t.Errorf("issue = %q", issues[0])
+	}
+	if buf.Len() != 0 {
+		t.Errorf("console got %q in dry-run mode", buf)
+	}
+	if got := readLog(t, dir); got != "" {
+		t.Errorf("file got %q in dry-run mode", got)
+	}
+}
+
+// BenchmarkFilteredOut measures log calls below the console level: the level is
+// checked before the caller lookup and formatting, so both variants cost the same.
+func BenchmarkFilteredOut(b *testing.B) {
+	for _, includeCaller := range []bool{true, false} {
+		b.Run(fmt.Sprintf("caller=%t", includeCaller), func(b *testing.B) {
+			l, _ := newTestLogger(b)
+			l.SetConsoleLevel(LevelWarn)
+			l.SetIncludeCaller(includeCaller)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				l.Debug("filtered %d", i)
+			}
+		})
+	}
+}
+
+// BenchmarkCaller compares written lines with and without the caller lookup.
+func BenchmarkCaller(b *testing.B) {
+	for _, includeCaller := range []bool{true, false} {
+		b.Run(fmt.Sprintf("caller=%t", includeCaller), func(b *testing.B) {
+			l, _ := newTestLogger(b)
+			l.SetIncludeCaller(includeCaller)
+			l.SetConsoleOutput(io.Discard, io.Discard)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				l.Info("written %d", i)
+			}
+		})
+	}
+}
+
+// currentLine returns the line number of its caller.
+func currentLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+func TestWithCallerSkip(t *testing.T) {
+	l, buf := newTestLogger(t)
+	wrapped := l.WithCallerSkip(1)
+	logInfo := func(msg string) { wrapped.Info("%s", msg) }
+
+	line := currentLine() + 1
+	logInfo("wrapped")
+	want := fmt.Sprintf("logger_test.go:%d - wrapped", line)
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("line = %q, want source %q", buf, want)
+	}
+
+	buf.Reset()
+	line = currentLine() + 1
+	l.Info("direct")
+	if want := fmt.Sprintf("logger_test.go:%d - direct", line); !strings.Contains(buf.String(), want) {
+		t.Errorf("default skip: line = %q, want source %q", buf, want)
+	}
+}
+
+func TestIncludeFunction(t *testing.T) {
+	l, buf := newTestLogger(t)
+	l.SetIncludeFunction(true)
+
+	line := currentLine() + 1
+	l.Info("named")
+	want := fmt.Sprintf("logger_test.go:%d (logger.TestIncludeFunction) - named", line)
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("line = %q, want %q", buf, want)
+	}
+
+	buf.Reset()
+	l.SetIncludeFunction(false)
+	l.Info("plain")
+	if strings.Contains(buf.String(), "(logger.") {
+		t.Errorf("function name still rendered: %q", buf)
+	}
+}
+
+func TestAsyncOrder(t *testing.T) {
+	dir := t.TempDir()
+	l := newFileLogger(t, dir)
+	l.SetAsync(16, OverflowBlock)
+
+	const n = 1000
+	for i := 0; i < n; i++ {
+		l.Info("msg %d", i)
+		if i == n/2 {
+			l.SetAsync(8, OverflowBlock) // switching queues keeps the order
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(readLog(t, dir), "\n"), "\n")
+	if len(lines) != n {
+		t.Fatalf("%d lines written, want %d", len(lines), n)
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, fmt.Sprintf(" - msg %d", i)) {
+			t.Fatalf("line %d = %q", i, line)
+		}
+	}
+}
+
+// BenchmarkAsync compares synchronous and asynchronous writes to a file.
+func BenchmarkAsync(b *testing.B) {
+	for _, buffer := range []int{0, 1024} {
+		b.Run(fmt.Sprintf("buffer=%d", buffer), func(b *testing.B) {
+			l, err := New(FileOnly, LevelDebug, LevelDebug, filepath.Join(b.TempDir(), "app.log"), 0)
+			if err != nil {
+				b.Fatal(err)
+			}
+			l.SetAsync(buffer, OverflowBlock)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				l.Info("message %d", i)
+			}
+			if err := l.Close(); err != nil {
+				b.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestAsyncDrop(t *testing.T) {
+	l, _ := newTestLogger(t)
+	w := &slowWriter{}
+	l.SetConsoleOutput(w, w)
+	l.SetAsync(1, OverflowDrop)
+
+	// Callers never wait for room in the buffer, so they outpace the writer
+	const goroutines, perGoroutine = 8, 200
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				l.Info("msg %d", i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	dropped := l.Dropped()
+	if dropped == 0 {
+		t.Fatal("Dropped() = 0 with a size-1 buffer")
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Written lines, dropped lines and the summary line add up
+	if got := uint64(w.lines.Load()); got+dropped != goroutines*perGoroutine+1 {
+		t.Errorf("%d lines written and %d dropped, want %d messages and a summary", got, dropped, goroutines*perGoroutine)
+	}
+}
+
+func TestFlush(t *testing.T) {
+	resetGlobal(t)
+	if err := Flush(); err != nil {
+		t.Errorf("Flush() without a logger = %v", err)
+	}
+
+	dir := t.TempDir()
+	l := newFileLogger(t, dir)
+	l.SetAsync(1024, OverflowBlock)
+
+	const n = 200
+	for i := 0; i < n; i++ {
+		l.Info("line %d", i)
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(readLog(t, dir), "\n"); got != n {
+		t.Errorf("%d lines readable after Flush, want %d", got, n)
+	}
+
+	console, _ := newTestLogger(t)
+	if err := console.Flush(); err != nil {
+		t.Errorf("console-only Flush() = %v", err)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	dir := t.TempDir()
+	clock := newTestClock()
+	l := newFileLogger(t, dir)
+	l.setClock(clock.now)
+
+	l.Info("before")
+	clock.add(time.Second)
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("after")
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	files := logFiles(t, dir)
+	if len(files) != 2 {
+		t.Fatalf("%d files, want 2", len(files))
+	}
+	rotated := filepath.Join(dir, "app_"+timestampSuffix(clock.now())+".log")
+	if files[0] == rotated {
+		files[0], files[1] = files[1], files[0]
+	}
+	if files[1] != rotated {
+		t.Errorf("files = %v, want a new file named %s", files, filepath.Base(rotated))
+	}
+	for i, msg := range []string{"before", "after"} {
+		got := readFile(t, files[i])
+		if strings.Count(got, "\n") != 1 || !strings.HasSuffix(got, " - "+msg+"\n") {
+			t.Errorf("%s = %q, want the %q line only", files[i], got, msg)
+		}
+	}
+
+	console, _ := newTestLogger(t)
+	if err := console.Rotate(); err != nil {
+		t.Errorf("Rotate in console-only mode: %v", err)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, level := range []LogLevel{LevelTrace, LevelDebug, LevelInfo, LevelWarn, LevelError} {
+		for _, s := range []string{level.String(), strings.ToLower(level.String()), " " + level.String() + "\n"} {
+			got, err := ParseLevel(s)
+			if err != nil || got != level {
+				t.Errorf("ParseLevel(%q) = %v, %v; want %v", s, got, err, level)
+			}
+		}
+	}
+	if got, err := ParseLevel("Warning"); err != nil || got != LevelWarn {
+		t.Errorf("ParseLevel(Warning) = %v, %v", got, err)
+	}
+	if _, err := ParseLevel("verbose"); err == nil || !strings.Contains(err.Error(), `"verbose"`) {
+		t.Errorf("ParseLevel(verbose) error = %v", err)
+	}
+}
+
+func TestStringers(t *testing.T) {
+	tests := []struct {
+		v    fmt.Stringer
+		want string
+	}{
+		{LevelTrace, "TRACE"},
+		{LevelDebug, "DEBUG"},
+		{LevelInfo, "INFO"},
+		{LevelWarn, "WARN"},
+		{LevelError, "ERROR"},
+		{LogLevel(7), "LogLevel(7)"},
+		{LogLevel(-5), "LogLevel(-5)"},
+		{ConsoleOnly, "ConsoleOnly"},
+		{FileOnly, "FileOnly"},
+		{Both, "Both"},
+		{OutputMode(7), "OutputMode(7)"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprint(tt.v); got != tt.want {
+			t.Errorf("%#v prints as %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestIsEnabled(t *testing.T) {
+	levels := []LogLevel{LevelDebug, LevelInfo, LevelWarn, LevelError}
+	for _, mode := range []OutputMode{ConsoleOnly, FileOnly, Both} {
+		l, err := NewWithWriter(mode, LevelInfo, LevelWarn, io.Discard)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, level := range levels {
+			wantConsole := mode != FileOnly && level >= LevelInfo
+			wantFile := mode != ConsoleOnly && level >= LevelWarn
+			if got := l.IsConsoleEnabled(level); got != wantConsole {
+				t.Errorf("%v: IsConsoleEnabled(%v) = %t", mode, level, got)
+			}
+			if got := l.IsFileEnabled(level); got != wantFile {
+				t.Errorf("%v: IsFileEnabled(%v) = %t", mode, level, got)
+			}
+			if got := l.IsEnabled(level); got != (wantConsole || wantFile) {
+				t.Errorf("%v: IsEnabled(%v) = %t", mode, level, got)
+			}
+		}
+		l.Close()
+	}
+
+	resetGlobal(t)
+	if IsEnabled(LevelError) {
+		t.Error("IsEnabled = true without a global logger")
+	}
+}
+
+func TestUTC(t *testing.T) {
+	dir := t.TempDir()
+	// The clock reports a non-UTC zone, so the conversion is visible even where Local is UTC
+	plus5 := time.FixedZone("PLUS5", 5*60*60)
+	clock := newTestClock()
+	l, err := New(Both, LevelDebug, LevelDebug, filepath.Join(dir, "app.log"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	buf := &bytes.Buffer{}
+	l.SetConsoleOutput(buf, buf)
+	if err := l.SetTimeFormat(time.RFC3339); err != nil {
+		t.Fatal(err)
+	}
+	l.SetMaxLines(1)
+	l.setClock(func() time.Time { return clock.now().In(plus5) })
+	l.SetUTC(true)
+
+	l.Info("first")
+	l.Info("second") // rotates; the file name uses UTC as well
+	if !strings.HasPrefix(buf.String(), "2036-02-02T23:10:15Z INFO: ") {
+		t.Errorf("line = %q", buf)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app_02.02.2036_23-10-15.000.log")); err != nil {
+		t.Errorf("rotated file is not named in UTC: %v", err)
+	}
+
+	buf.Reset()
+	l.SetUTC(false)
+	l.Info("local")
+	if want := clock.now().In(time.Local).Format(time.RFC3339) + " INFO: "; !strings.HasPrefix(buf.String(), want) {
+		t.Errorf("local line = %q, want prefix %q", buf, want)
+	}
+}
+
+func TestLocation(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no time zone database: %v", err)
+	}
+	clock := newTestClock()
+	l, buf := newTestLogger(t)
+	if err := l.SetTimeFormat(time.RFC3339); err != nil {
+		t.Fatal(err)
+	}
+	l.SetLocation(ny)
+	l.setClock(clock.now)
+
+	l.Info("winter")
+	clock.add(180 * 24 * time.Hour)
+	l.Info("summer")
+	lines := strings.Split(buf.String(), "\n")
+	if !strings.HasPrefix(lines[0], "2036-02-02T18:10:15-05:00 INFO: ") {
+		t.Errorf("winter line = %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "2036-07-31T19:10:15-04:00 INFO: ") {
+		t.Errorf("summer line = %q", lines[1])
+	}
+
+	buf.Reset()
+	l.SetLocation(nil) // falls back to Local
+	l.Info("local")
+	if want := clock.now().In(time.Local).Format(time.RFC3339); !strings.HasPrefix(buf.String(), want) {
+		t.Errorf("line = %q, want prefix %q", buf, want)
+	}
+}
+
+func TestTimeLayouts(t *testing.T) {
+	clock := newTestClock()
+	l, buf := newTestLogger(t)
+	l.setClock(clock.now)
+	l.SetUTC(true)
+
+	tests := []struct {
+		layout string
+		want   string
+	}{
+		{"", "2036/02/02 23:10:15"},
+		{time.RFC3339, "2036-02-02T23:10:15Z"},
+		{"15:04 02.01", "23:10 02.02"},
+		{TimeFormatUnix, strconv.FormatInt(clock.now().Unix(), 10)},
+		{TimeFormatUnixMilli, strconv.FormatInt(clock.now().UnixMilli(), 10)},
+	}
+	for _, tt := range tests {
+		if err := l.SetTimeFormat(tt.layout); err != nil {
+			t.Fatalf("SetTimeFormat(%q): %v", tt.layout, err)
+		}
+		buf.Reset()
+		l.Info("msg")
+		if want := tt.want + " INFO: logger_test.go:"; !strings.HasPrefix(buf.String(), want) {
+			t.Errorf("layout %q: line = %q, want prefix %q", tt.layout, buf, want)
+		}
+	}
+}
+
+// csvFormatter is an example custom Formatter rendering "time,level,source,msg[,key=value...]".
+type csvFormatter struct{}
+
+func (csvFormatter) Format(level LogLevel, t time.Time, source, msg string, fields Fields) []byte {
+	record := []string{t.UTC().Format(time.RFC3339), level.String(), source, msg}
+	for _, key := range sortedKeys(fields) {
+		record = append(record, fmt.Sprintf("%s=%v", key, fields[key]))
+	}
+	return []byte(strings.Join(record, ",") + "\n")
+}
+
+func TestCustomFormatter(t *testing.T) {
+	clock := newTestClock()
+	l, buf := newTestLogger(t)
+	l.setClock(clock.now)
+	l.SetFormatter(csvFormatter{})
+
+	line := currentLine() + 1
+	l.WithFields(Fields{"b": 2, "a": 1}).Warn("custom")
+	want := fmt.Sprintf("2036-02-02T23:10:15Z,WARN,logger_test.go:%d,custom,a=1,b=2\n", line)
+	if buf.String() != want {
+		t.Errorf("line = %q, want %q", buf, want)
+	}
+
+	buf.Reset()
+	l.SetFormatter(nil) // back to the built-in text format
+	l.Info("text")
+	if !strings.Contains(buf.String(), " INFO: logger_test.go:") {
+		t.Errorf("line = %q", buf)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	clock := newTestClock()
+	l, buf := newTestLogger(t)
+	l.setClock(clock.now)
+	l.SetRateLimit(LevelError, 10, time.Second)
+
+	for i := 0; i < 1000; i++ {
+		l.Error("flood %d", i)
+	}
+	if n := strings.Count(buf.String(), " - flood "); n != 10 {
+		t.Errorf("%d lines written, want the burst of 10", n)
+	}
+
+	clock.add(time.Second) // refills the bucket
+	l.Error("recovered")
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if n := len(lines); n != 12 {
+		t.Fatalf("%d lines, want 12:\n%s", n, buf)
+	}
+	if !strings.HasSuffix(lines[10], " - ... 990 messages suppressed") || !strings.HasSuffix(lines[11], " - recovered") {
+		t.Errorf("tail = %q", lines[10:])
+	}
+
+	buf.Reset()
+	l.Info("other levels are not limited")
+	if buf.Len() == 0 {
+		t.Error("INFO line was rate limited")
+	}
+}
+
+func TestDeduplicate(t *testing.T) {
+	l, buf := newTestLogger(t)
+	l.SetDeduplicate(true)
+
+	for i := 0; i < 5; i++ {
+		l.Error("disk full")
+	}
+	l.Error("disk ok")
+	for i := 0; i < 2; i++ {
+		l.Info("same")
+	}
+	if err := l.Flush(); err != nil { // reports pending repeats
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []string{"disk full", "last message repeated 4 times", "disk ok", "same", "last message repeated 1 times"}
+	if len(lines) != len(want) {
+		t.Fatalf("lines = %q", lines)
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, " - "+want[i]) {
+			t.Errorf("line %d = %q, want message %q", i, line, want[i])
+		}
+	}
+}
+
+var errDiskFull = errors.New("disk full")
+
+// failingWriter is a writer whose writes always fail.
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errDiskFull }
+
+func TestOnError(t *testing.T) {
+	l, err := NewWithWriter(FileOnly, LevelDebug, LevelDebug, failingWriter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []error
+	l.OnError(func(err error) { got = append(got, err) })
+
+	l.Info("first")
+	l.Info("second")
+	if len(got) != 2 {
+		t.Fatalf("callback fired %d times, want 2", len(got))
+	}
+	if !errors.Is(got[0], errDiskFull) {
+		t.Errorf("err = %v, want it to wrap %v", got[0], errDiskFull)
+	}
+	if err := l.LastError(); !errors.Is(err, errDiskFull) {
+		t.Errorf("LastError() = %v", err)
+	}
+}
+
+func TestCurrentFilePath(t *testing.T) {
+	resetGlobal(t)
+	if got := CurrentFilePath(); got != "" {
+		t.Errorf("CurrentFilePath() = %q without a logger", got)
+	}
+
+	dir := t.TempDir()
+	if err := Init(FileOnly, LevelInfo, LevelInfo, filepath.Join(dir, "app.log"), 0); err != nil {
+		t.Fatal(err)
+	}
+	Info("first")
+	first := CurrentFilePath()
+	if err := Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	Info("second")
+	if err := Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	second := CurrentFilePath()
+	if second == first {
+		t.Fatalf("path did not change after Rotate: %s", second)
+	}
+	if !strings.Contains(readFile(t, second), " - second") {
+		t.Errorf("%s does not hold the line written after Rotate", second)
+	}
+	if files := logFiles(t, dir); len(files) != 2 || (files[0] != second && files[1] != second) {
+		t.Errorf("files on disk = %v, active %s", files, second)
+	}
+
+	MustReinit(ConsoleOnly, LevelInfo, LevelInfo, "", 0)
+	if got := CurrentFilePath(); got != "" {
+		t.Errorf("CurrentFilePath() = %q in console-only mode", got)
+	}
+}
+
+// rotatedName matches timestamped log file names, including the collision suffix.
+var rotatedName = regexp.MustCompile(`^app_\d{2}\.\d{2}\.\d{4}_\d{2}-\d{2}-\d{2}\.\d{3}(_\d{2})?\.log$`)
+
+func TestInitialFileName(t *testing.T) {
+	dir := t.TempDir()
+	l := newFileLogger(t, dir)
+
+	first := filepath.Base(l.CurrentFilePath())
+	if !rotatedName.MatchString(first) {
+		t.Errorf("initial file %s does not follow the rotated naming", first)
+	}
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if second := filepath.Base(l.CurrentFilePath()); !rotatedName.MatchString(second) {
+		t.Errorf("rotated file %s does not follow the naming", second)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app.log")); !os.IsNotExist(err) {
+		t.Errorf("the base path was created: %v", err)
+	}
+}
+
+func TestTinyMaxSizeNoEmptyFiles(t *testing.T) {
+	dir := t.TempDir()
+	clock := newTestClock()
+	l, err := New(FileOnly, LevelDebug, LevelDebug, filepath.Join(dir, "app.log"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.setClock(clock.now)
+
+	for i := 0; i < 5; i++ {
+		clock.add(time.Second)
+		l.Info("line longer than the limit %d", i)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files := logFiles(t, dir)
+	if len(files) != 5 {
+		t.Errorf("%d files, want one per line", len(files))
+	}
+	for _, path := range files {
+		if got := readFile(t, path); strings.Count(got, "\n") != 1 {
+			t.Errorf("%s = %q, want exactly one line", filepath.Base(path), got)
+		}
+	}
+}
+
+func TestReopen(t *testing.T) {
+	dir := t.TempDir()
+	l := newFileLogger(t, dir)
+
+	l.Info("before")
+	path := l.CurrentFilePath()
+	moved := path + ".1"
+	if err := os.Rename(path, moved); err != nil { // what logrotate does
+		t.Fatal(err)
+	}
+	l.Info("still in the moved file")
+	if err := l.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("after")
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if l.CurrentFilePath() != path {
+		t.Errorf("path changed to %s", l.CurrentFilePath())
+	}
+	if got := readFile(t, moved); strings.Count(got, "\n") != 2 || strings.Contains(got, " - after") {
+		t.Errorf("moved file = %q", got)
+	}
+	if got := readFile(t, path); strings.Count(got, "\n") != 1 || !strings.HasSuffix(got, " - after\n") {
+		t.Errorf("reopened file = %q", got)
+	}
+}
+
+func TestAddWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := New(FileOnly, LevelDebug, LevelInfo, path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	var first, second bytes.Buffer
+	l.AddWriter(&first)
+	l.AddWriter(failingWriter{}) // does not stop the others
+	l.AddWriter(&second)
+
+	l.Debug("below the file level")
+	l.Info("one")
+	l.Warn("two")
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	file := readFile(t, l.CurrentFilePath())
+	if strings.Count(file, "\n") != 2 {
+		t.Fatalf("file = %q", file)
+	}
+	if first.String() != file || second.String() != file {
+		t.Errorf("writers got %q and %q, want the file content %q", first.String(), second.String(), file)
+	}
+	if !errors.Is(l.LastError(), errDiskFull) {
+		t.Errorf("LastError() = %v", l.LastError())
+	}
+}
+
+func TestLevelFile(t *testing.T) {
+	dir := t.TempDir()
+	l, err := New(Both, LevelDebug, LevelDebug, filepath.Join(dir, "app.log"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	buf := &bytes.Buffer{}
+	l.SetConsoleOutput(buf, buf)
+	if err := l.SetLevelFile(LevelError, filepath.Join(dir, "errors", "error.log")); err != nil {
+		t.Fatal(err)
+	}
+
+	l.Debug("d")
+	l.Info("i")
+	l.Warn("w")
+	l.Error("e")
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	errFiles := logFiles(t, filepath.Join(dir, "errors"))
+	if len(errFiles) != 1 {
+		t.Fatalf("error files = %v", errFiles)
+	}
+	if got := readFile(t, errFiles[0]); strings.Count(got, "\n") != 1 || !strings.Contains(got, "ERROR: ") {
+		t.Errorf("error file = %q, want the error line only", got)
+	}
+	if got := readFile(t, l.CurrentFilePath()); strings.Count(got, "\n") != 3 || strings.Contains(got, "ERROR") {
+		t.Errorf("main file = %q, want the other three lines", got)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 4 {
+		t.Errorf("console got %d lines, want all 4", n)
+	}
+
+	// Rotate applies to level files too
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(logFiles(t, filepath.Join(dir, "errors"))); n != 2 {
+		t.Errorf("%d error files after Rotate, want 2", n)
+	}
+}
+
+func TestDefaultFields(t *testing.T) {
+	l, buf := newTestLogger(t)
+	l.SetDefaultFields(Fields{"service": "api", "host": "h1"})
+
+	l.WithFields(Fields{"host": "h2", "req": 1}).Info("call")
+	if !strings.HasSuffix(buf.String(), " - call host=h2 req=1 service=api\n") {
+		t.Errorf("text line = %q", buf)
+	}
+
+	buf.Reset()
+	l.SetFormat(FormatJSON)
+	l.Info("plain")
+	var rec map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["service"] != "api" || rec["host"] != "h1" {
+		t.Errorf("JSON record = %v", rec)
+	}
+}
+
+func TestStackOnError(t *testing.T) {
+	l, buf := newTestLogger(t)
+	l.SetStackOnError(true)
+
+	l.Warn("no stack")
+	if strings.Contains(buf.String(), "TestStackOnError") {
+		t.Errorf("WARN line has a stack: %q", buf)
+	}
+
+	buf.Reset()
+	l.Error("failed")
+	lines := strings.Split(buf.String(), "\n")
+	if !strings.HasSuffix(lines[0], " - failed") || !strings.HasPrefix(lines[1], "github.com/ZeRg0912/logger.TestStackOnError") {
+		t.Errorf("text stack does not start at the test:\n%s", buf)
+	}
+	if strings.Contains(buf.String(), "logger.(*Logger).") {
+		t.Errorf("stack has logger frames:\n%s", buf)
+	}
+
+	buf.Reset()
+	l.SetFormat(FormatJSON)
+	l.Error("failed")
+	var rec map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if stack, _ := rec[StackField].(string); !strings.Contains(stack, "TestStackOnError") {
+		t.Errorf("JSON stack field = %q", stack)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	l, buf := newTestLogger(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	panicLine := currentLine() + 2
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	handler := l.Middleware(mux)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/ok", nil))
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], "INFO: ") || !strings.Contains(lines[0], " - http request duration=") ||
+		!strings.HasSuffix(lines[0], " method=POST path=/ok status=201") {
+		t.Errorf("request lines = %q", lines)
+	}
+
+	buf.Reset()
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rec.Code)
+	}
+	out := buf.String()
+	if want := fmt.Sprintf("ERROR: logger_test.go:%d - panic: boom", panicLine); !strings.Contains(out, want) {
+		t.Errorf("output lacks %q:\n%s", want, out)
+	}
+	if !strings.HasSuffix(out, " method=GET path=/panic status=500\n") {
+		t.Errorf("request line after a panic:\n%s", out)
+	}
+}
+
+// slowWriter is a writer that takes a millisecond per write.
+type slowWriter struct {
+	lines atomic.Int64
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	w.lines.Add(1)
+	return len(p), nil
+}
+
+func TestStats(t *testing.T) {
+	l, _ := newTestLogger(t)
+	l.SetConsoleLevel(LevelInfo)
+	for i := 0; i < 3; i++ {
+		l.Info("i")
+	}
+	l.Warn("w")
+	for i := 0; i < 2; i++ {
+		l.Error("e")
+	}
+	l.Debug("below the level")
+	l.SetDryRun(true)
+	l.Error("not written in dry-run mode")
+	l.SetDryRun(false)
+
+	want := map[LogLevel]uint64{LevelTrace: 0, LevelDebug: 0, LevelInfo: 3, LevelWarn: 1, LevelError: 2}
+	stats := l.Stats()
+	for level, n := range want {
+		if stats[level] != n {
+			t.Errorf("Stats()[%v] = %d, want %d", level, stats[level], n)
+		}
+	}
+
+	stats[LevelInfo] = 100 // a copy
+	if l.Stats()[LevelInfo] != 3 {
+		t.Error("Stats() returned the internal map")
+	}
+}
+
+func TestRecent(t *testing.T) {
+	l, _ := newTestLogger(t)
+	if l.Recent(0) != nil {
+		t.Error("Recent returned lines with the buffer disabled")
+	}
+	l.SetRecentBuffer(3)
+
+	l.Info("0")
+	if got := l.Recent(5); len(got) != 1 || !strings.HasSuffix(got[0], " - 0\n") {
+		t.Errorf("Recent(5) before wrapping = %q", got)
+	}
+	for i := 1; i < 10; i++ {
+		l.Info("%d", i)
+	}
+
+	got := l.Recent(0)
+	if len(got) != 3 {
+		t.Fatalf("Recent(0) = %q, want 3 lines", got)
+	}
+	for i, want := range []string{"7", "8", "9"} {
+		if !strings.HasSuffix(got[i], " - "+want+"\n") {
+			t.Errorf("line %d = %q, want message %s", i, got[i], want)
+		}
+	}
+	if got := l.Recent(2); len(got) != 2 || !strings.HasSuffix(got[1], " - 9\n") {
+		t.Errorf("Recent(2) = %q", got)
+	}
+}
+
+func TestNewWithOptions(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := NewWithOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.GetOutputMode() != ConsoleOnly || l.CurrentFilePath() != "" {
+		t.Errorf("defaults: mode %v, file %q", l.GetOutputMode(), l.CurrentFilePath())
+	}
+
+	l, err = NewWithOptions(
+		WithOutputMode(Both),
+		WithConsoleLevel(LevelWarn),
+		WithFileLevel(LevelInfo),
+		WithFile(filepath.Join(dir, "app.log")),
+		WithMaxSize(1<<20),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if !l.IsFileEnabled(LevelInfo) || l.IsConsoleEnabled(LevelInfo) || !l.IsConsoleEnabled(LevelWarn) {
+		t.Error("levels were not applied")
+	}
+	if n := len(logFiles(t, dir)); n != 1 {
+		t.Errorf("%d files created, want 1", n)
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWithOptions(WithOutputMode(FileOnly), WithWriter(&buf), WithFormat(FormatJSON), WithOmitSource(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Info("to the writer")
+	if !strings.HasPrefix(buf.String(), `{"time":`) || strings.Contains(buf.String(), `"source"`) {
+		t.Errorf("writer got %q", buf.String())
+	}
+
+	for name, opts := range map[string][]Option{
+		"file mode without a file": {WithOutputMode(FileOnly)},
+		"invalid mode":             {WithOutputMode(OutputMode(7))},
+		"negative mode":            {WithOutputMode(OutputMode(-1))},
+		"invalid level":            {WithConsoleLevel(LogLevel(9))},
+		"nil writer":               {WithWriter(nil)},
+	} {
+		if _, err := NewWithOptions(opts...); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestConfigValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		c    Config
+		want string
+	}{
+		{"FileOnly without path", Config{OutputMode: FileOnly}, "file path required for FileOnly mode"},
+		{"Both without path", Config{OutputMode: Both}, "file path required for Both mode"},
+		{"invalid mode", Config{OutputMode: OutputMode(5)}, "invalid output mode OutputMode(5)"},
+		{"invalid console level", Config{ConsoleLevel: LogLevel(9)}, "invalid console level LogLevel(9)"},
+		{"invalid file level", Config{FileLevel: LogLevel(-3)}, "invalid file level LogLevel(-3)"},
+		{"negative size", Config{MaxFileSize: -1}, "max file size must not be negative"},
+		{"negative lines", Config{MaxLines: -1}, "max lines must not be negative"},
+		{"negative backups", Config{MaxBackups: -1}, "max backups must not be negative"},
+		{"negative age", Config{MaxAge: -time.Hour}, "max age must not be negative"},
+		{"invalid format", Config{Format: Format(42)}, "invalid format"},
+		{"broken time format", Config{TimeFormat: "no layout"}, "logger: "},
+	}
+	for _, tt := range tests {
+		l, err := NewFromConfig(tt.c)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want it to contain %q", tt.name, err, tt.want)
+		}
+		if l != nil {
+			t.Errorf("%s: got a logger along with the error", tt.name)
+		}
+	}
+
+	l, err := NewFromConfig(Config{OutputMode: FileOnly, FilePath: filepath.Join(t.TempDir(), "app.log"), FileLevel: LevelWarn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if l.IsFileEnabled(LevelInfo) || !l.IsFileEnabled(LevelWarn) {
+		t.Error("valid config was not applied")
+	}
+}
+
+func TestInitValidation(t *testing.T) {
+	resetGlobal(t)
+	for name, init := range map[string]func() error{
+		"FileOnly without path": func() error { return Init(FileOnly, LevelInfo, LevelInfo, "", 0) },
+		"Both without path":     func() error { return InitBoth(LevelInfo, LevelInfo, "", 0) },
+		"invalid console level": func() error { return InitConsoleOnly(LogLevel(10)) },
+		"invalid file level":    func() error { return InitFileOnly(LogLevel(-7), "app.log", 0) },
+	} {
+		if err := init(); err == nil {
+			t.Errorf("%s: no error", name)
+			Reset()
+		}
+		if getDefault() != nil {
+			t.Errorf("%s: global logger was installed", name)
+		}
+	}
+
+	// Console-only without a path keeps working
+	if err := Init(ConsoleOnly, LevelInfo, LevelInfo, "", 0); err != nil {
+		t.Errorf("console-only Init: %v", err)
+	}
+}
+
+func TestGetLogger(t *testing.T) {
+	resetGlobal(t)
+	t.Cleanup(func() { _ = closeRegistry() })
+	global, buf := newTestLogger(t)
+	defer ReplaceGlobal(global)()
+
+	api := GetLogger("api")
+	if GetLogger("api") != api {
+		t.Error("same name returned a different instance")
+	}
+	db := GetLogger("db")
+	if db == api {
+		t.Fatal("different names returned the same instance")
+	}
+
+	api.Info("from api")
+	db.Info("from db")
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], " - from api logger=api") || !strings.HasSuffix(lines[1], " - from db logger=db") {
+		t.Errorf("lines = %q", lines)
+	}
+
+	// A configured logger has its own output and is closed with the package
+	dir := t.TempDir()
+	if err := SetLoggerConfig("audit", Config{OutputMode: FileOnly, FilePath: filepath.Join(dir, "audit.log")}); err != nil {
+		t.Fatal(err)
+	}
+	audit := GetLogger("audit")
+	audit.Info("audited")
+	auditPath := audit.CurrentFilePath()
+	if strings.Contains(buf.String(), "audited") {
+		t.Error("configured logger wrote to the global output")
+	}
+	if err := Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, auditPath); !strings.HasSuffix(got, " - audited logger=audit\n") {
+		t.Errorf("audit file = %q", got)
+	}
+	audit.Info("after close")
+	if got := readFile(t, auditPath); strings.Contains(got, "after close") {
+		t.Error("registered logger was not closed by Close")
+	}
+	if GetLogger("api") == api {
+		t.Error("Close kept the registry entries")
+	}
+}
+
+func TestGetLoggerAfterReset(t *testing.T) {
+	resetGlobal(t)
+	dir := t.TempDir()
+	if err := Init(FileOnly, LevelInfo, LevelInfo, filepath.Join(dir, "old.log"), 0); err != nil {
+		t.Fatal(err)
+	}
+	old := GetLogger("api")
+	oldPath := CurrentFilePath()
+
+	if err := Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Init(FileOnly, LevelInfo, LevelInfo, filepath.Join(dir, "new.log"), 0); err != nil {
+		t.Fatal(err)
+	}
+	api := GetLogger("api")
+	if api == old {
+		t.Fatal("Reset kept the registry entry bound to the closed logger")
+	}
+	api.Info("after reinit")
+	if got := readFile(t, CurrentFilePath()); !strings.HasSuffix(got, " - after reinit logger=api\n") {
+		t.Errorf("new file = %q", got)
+	}
+	if got := readFile(t, oldPath); strings.Contains(got, "after reinit") {
+		t.Error("named logger wrote to the closed global logger")
+	}
+
+	MustReinit(FileOnly, LevelInfo, LevelInfo, filepath.Join(dir, "reinit.log"), 0)
+	if GetLogger("api") == api {
+		t.Error("MustReinit kept the registry entry")
+	}
+}
+
+func TestWithPrefix(t *testing.T) {
+	l, buf := newTestLogger(t)
+	api := l.WithPrefix("api")
+	auth := api.WithPrefix("auth")
+
+	auth.Info("login")
+	if !strings.Contains(buf.String(), " INFO [api][auth]: logger_test.go:") {
+		t.Errorf("nested prefix: %q", buf)
+	}
+
+	buf.Reset()
+	api.Warn("request")
+	if !strings.Contains(buf.String(), " WARN [api]: ") {
+		t.Errorf("child prefix changed by WithPrefix: %q", buf)
+	}
+
+	buf.Reset()
+	l.Info("plain")
+	if strings.Contains(buf.String(), "[api]") {
+		t.Errorf("parent got the prefix: %q", buf)
+	}
+}
+
+func TestNetworkSink(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no TCP loopback: %v", err)
+	}
+	defer ln.Close()
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			received <- nil
+			return
+		}
+		defer conn.Close()
+		var lines []string
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		received <- lines
+	}()
+
+	l, _ := newTestLogger(t)
+	if _, err := l.AddNetworkSink("tcp://"+ln.Addr().String(), LevelInfo); err != nil {
+		t.Fatal(err)
+	}
+	l.Debug("below the sink level")
+	l.Info("one")
+	l.Error("two")
+	if err := l.Close(); err != nil { // drains the sink and closes the connection
+		t.Fatal(err)
+	}
+
+	select {
+	case lines := <-received:
+		if len(lines) != 2 || !strings.HasSuffix(lines[0], " - one") || !strings.HasSuffix(lines[1], " - two") {
+			t.Errorf("collector got %q", lines)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("collector got nothing")
+	}
+}
+
+func TestNetworkWriterFallback(t *testing.T) {
+	// A listener closed right away leaves a port nobody accepts on
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no TCP loopback: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	var fallback bytes.Buffer
+	w, err := NewNetworkWriter("tcp://"+addr, &fallback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, err := io.WriteString(w, "kept\n"); err != nil {
+		t.Fatal(err)
+	}
+	if fallback.String() != "kept\n" {
+		t.Errorf("fallback = %q", fallback.String())
+	}
+
+	if _, err := NewNetworkWriter("http://"+addr, nil); err == nil {
+		t.Error("unsupported scheme accepted")
+	}
+}
+
+func TestLineEnding(t *testing.T) {
+	dir := t.TempDir()
+	clock := newTestClock()
+	// Each line is "2036/02/02 23:10:15 INFO: msgN\r\n", 32 bytes: two lines exceed 63 bytes
+	// only if the "\r" is counted
+	l, buf := newTestLogger(t, WithOutputMode(Both), WithFile(filepath.Join(dir, "app.log")),
+		WithLineEnding("\r\n"), WithOmitSource(true), WithMaxSize(63), WithLocation(time.UTC))
+	l.setClock(clock.now)
+
+	for i := 1; i <= 2; i++ {
+		clock.add(time.Second)
+		l.Info("msg%d", i)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(buf.String(), " INFO: msg2\r\n") || strings.Count(buf.String(), "\r\n") != 2 {
+		t.Errorf("console = %q", buf)
+	}
+	var contents []string
+	for _, path := range logFiles(t, dir) {
+		if got := readFile(t, path); got != "" {
+			contents = append(contents, got)
+		}
+	}
+	if len(contents) != 2 {
+		t.Fatalf("non-empty files = %q, want one line each", contents)
+	}
+	for _, got := range contents {
+		if len(got) != 32 || !strings.HasSuffix(got, "\r\n") {
+			t.Errorf("file = %q, want one 32-byte CRLF line", got)
+		}
+	}
+}
+
+func TestConsoleHelperLabels(t *testing.T) {
+	resetGlobal(t)
+	l, buf := newTestLogger(t)
+	defer ReplaceGlobal(l)()
+	l.SetEmoji(true) // a buffer is not a terminal, so labels stay plain
+
+	ConsoleError("e %d", 1)
+	ConsoleWarn("w")
+	ConsoleInfo("i")
+	ConsoleSuccess("s")
+	want := "Error: e 1\nWarning: w\nInfo: i\nSuccess: s\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf, want)
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, buf := newTestLogger(t, WithOutputMode(Both), WithFile(path))
+	defer l.Close()
+	if err := l.SetRedactPatterns(`Bearer \S+`, `password=\S+`); err != nil {
+		t.Fatal(err)
+	}
+	l.SetRedactFields("token")
+
+	fields := Fields{"token": "abc123", "user": "ann"}
+	l.WithFields(fields).Info("auth header Bearer eyJhbGci.x.y, password=hunter2 ok")
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := " - auth header *** *** ok token=*** user=ann\n"
+	for name, got := range map[string]string{"console": buf.String(), "file": readFile(t, l.CurrentFilePath())} {
+		if !strings.HasSuffix(got, want) || strings.Contains(got, "hunter2") || strings.Contains(got, "abc123") {
+			t.Errorf("%s = %q, want suffix %q", name, got, want)
+		}
+	}
+	if fields["token"] != "abc123" {
+		t.Error("redaction modified the caller's fields")
+	}
+	if err := l.SetRedactPatterns("("); err == nil {
+		t.Error("invalid pattern accepted")
+	}
+}
+
+// BenchmarkInfo reports allocs/op of Info at an enabled level; lines are rendered
+// into pooled buffers (see linePool).
+func BenchmarkInfo(b *testing.B) {
+	for name, format := range map[string]Format{"text": FormatText, "json": FormatJSON} {
+		b.Run(name, func(b *testing.B) {
+			l, _ := newTestLogger(b, WithFormat(format), WithIncludeCaller(false))
+			l.SetConsoleOutput(io.Discard, io.Discard)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				l.Info("request %d handled", i)
+			}
+		})
+	}
+}
+
+func TestPooledLinesDoNotLeak(t *testing.T) {
+	l, buf := newTestLogger(t, WithOmitSource(true))
+	l.Info("%s", strings.Repeat("x", 300)) // grows the pooled buffer
+	l.Info("short")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[1], " INFO: short") {
+		t.Errorf("second line = %q", lines[len(lines)-1])
+	}
+}
+
+// countingWriter counts Write calls, standing in for write syscalls.
+type countingWriter struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes++
+	return w.buf.Write(p)
+}
+
+func TestBatchingKeepsLines(t *testing.T) {
+	w := &countingWriter{}
+	l, _ := newTestLogger(t, WithOutputMode(FileOnly), WithWriter(w), WithOmitSource(true))
+	l.SetBatching(100, time.Hour) // flushed by size, Flush and Close only
+
+	const goroutines, perGoroutine = 4, 250
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				l.Info("g%d line %d", g, i)
+				if i == perGoroutine/2 {
+					_ = l.Flush()
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	l.Info("last")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(w.buf.String(), "\n"), "\n")
+	if len(lines) != goroutines*perGoroutine+1 {
+		t.Fatalf("%d lines written, want %d", len(lines), goroutines*perGoroutine+1)
+	}
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		i := strings.Index(line, "INFO: ")
+		if i < 0 {
+			t.Fatalf("broken line %q", line)
+		}
+		seen[line[i+len("INFO: "):]] = true
+	}
+	for g := 0; g < goroutines; g++ {
+		for i := 0; i < perGoroutine; i++ {
+			if msg := fmt.Sprintf("g%d line %d", g, i); !seen[msg] {
+				t.Fatalf("%q was lost", msg)
+			}
+		}
+	}
+	if !strings.HasSuffix(lines[len(lines)-1], " INFO: last") {
+		t.Errorf("last line = %q", lines[len(lines)-1])
+	}
+	if w.writes >= len(lines) {
+		t.Errorf("%d writes for %d lines, batching did not coalesce", w.writes, len(lines))
+	}
+}
+
+// BenchmarkBatching reports writes per logged line with and without batching.
+func BenchmarkBatching(b *testing.B) {
+	for _, size := range []int{0, 64 << 10} {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			w := &countingWriter{}
+			l, _ := newTestLogger(b, WithOutputMode(FileOnly), WithWriter(w))
+			l.SetBatching(size, time.Second)
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					l.Info("concurrent line")
+				}
+			})
+			_ = l.Close()
+			b.ReportMetric(float64(w.writes)/float64(b.N), "writes/op")
+		})
+	}
+}
+
+func TestTraceLevel(t *testing.T) {
+	l, buf := newTestLogger(t, WithConsoleLevel(LevelDebug))
+	l.Trace("hidden %d", 1)
+	if buf.Len() != 0 {
+		t.Errorf("TRACE written at the Debug threshold: %q", buf)
+	}
+	if l.IsEnabled(LevelTrace) {
+		t.Error("IsEnabled(LevelTrace) = true at the Debug threshold")
+	}
+
+	l.SetConsoleLevel(LevelTrace)
+	line := currentLine() + 1
+	l.Trace("shown %d", 2)
+	if want := fmt.Sprintf("TRACE: logger_test.go:%d - shown 2\n", line); !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("line = %q, want suffix %q", buf, want)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, _ := newTestLogger(t, WithOutputMode(FileOnly), WithFile(path))
+	defer l.Close()
+	l.SetBatching(4096, time.Hour) // Panic must flush the batch
+
+	var line int
+	func() {
+		defer func() {
+			if r := recover(); r != "bad state: 42" {
+				t.Errorf("panic value = %#v", r)
+			}
+		}()
+		line = currentLine() + 1
+		l.Panic("bad state: %d", 42)
+	}()
+
+	want := fmt.Sprintf("ERROR: logger_test.go:%d - bad state: 42\n", line)
+	if got := readFile(t, l.CurrentFilePath()); !strings.HasSuffix(got, want) {
+		t.Errorf("file = %q, want suffix %q", got, want)
+	}
+}
+
+func TestInitFromEnv(t *testing.T) {
+	resetGlobal(t)
+	t.Setenv("LOG_LEVEL", "")
+	t.Setenv("LOG_FILE", "")
+	t.Setenv("LOG_MODE", "")
+
+	if err := InitFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if GetOutputMode() != ConsoleOnly || GetConsoleLevel() != LevelInfo {
+		t.Errorf("defaults: mode %v, console level %v", GetOutputMode(), GetConsoleLevel())
+	}
+	Reset()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	t.Setenv("LOG_LEVEL", "Warning")
+	t.Setenv("LOG_FILE", path)
+	t.Setenv("LOG_MODE", "file")
+	if err := InitFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if GetOutputMode() != FileOnly || GetFileLevel() != LevelWarn || CurrentFilePath() == "" {
+		t.Errorf("mode %v, file level %v, file %q", GetOutputMode(), GetFileLevel(), CurrentFilePath())
+	}
+	Reset()
+
+	for _, tt := range []struct{ key, value, want string }{
+		{"LOG_LEVEL", "loud", "LOG_LEVEL"},
+		{"LOG_MODE", "cloud", "LOG_MODE"},
+	} {
+		t.Setenv(tt.key, tt.value)
+		if err := InitFromEnv(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s=%s: err = %v", tt.key, tt.value, err)
+		}
+		t.Setenv(tt.key, "")
+	}
+	t.Setenv("LOG_FILE", "")
+	t.Setenv("LOG_MODE", "both")
+	if err := InitFromEnv(); err == nil || !strings.Contains(err.Error(), "requires LOG_FILE") {
+		t.Errorf("LOG_MODE=both without LOG_FILE: err = %v", err)
+	}
+}
+
+func TestSetOutputMode(t *testing.T) {
+	dir := t.TempDir()
+	l, buf := newTestLogger(t, WithFile(filepath.Join(dir, "app.log")))
+	defer l.Close()
+	if n := len(logFiles(t, dir)); n != 0 {
+		t.Fatalf("console-only logger created %d files", n)
+	}
+
+	if err := l.SetOutputMode(Both); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("captured")
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, l.CurrentFilePath()); !strings.HasSuffix(got, " - captured\n") {
+		t.Errorf("file = %q", got)
+	}
+	if !strings.Contains(buf.String(), " - captured") {
+		t.Errorf("console = %q", buf)
+	}
+
+	if err := l.SetOutputMode(ConsoleOnly); err != nil {
+		t.Fatal(err)
+	}
+	if l.CurrentFilePath() != "" {
+		t.Errorf("file still open: %s", l.CurrentFilePath())
+	}
+
+	console, _ := newTestLogger(t)
+	if err := console.SetOutputMode(FileOnly); err == nil {
+		t.Error("switched to FileOnly without a path")
+	}
+	if console.GetOutputMode() != ConsoleOnly {
+		t.Errorf("failed switch changed the mode to %v", console.GetOutputMode())
+	}
+}
+
+func TestConsoleHelpersDuringModeSwitch(t *testing.T) {
+	resetGlobal(t)
+	l, _ := newTestLogger(t, WithFile(filepath.Join(t.TempDir(), "app.log")))
+	l.SetConsoleOutput(io.Discard, io.Discard)
+	defer ReplaceGlobal(l)()
+	defer l.Close()
+
+	// Run with -race: the helpers read the mode while it changes
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			ConsoleWarn("warn %d", i)
+			ConsoleSuccess("ok %d", i)
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		_ = l.SetOutputMode([]OutputMode{ConsoleOnly, Both, FileOnly}[i%3])
+	}
+	<-done
+}
+
+func TestSizeResyncAfterTruncate(t *testing.T) {
+	dir := t.TempDir()
+	clock := newTestClock()
+	// Lines are "2036/02/02 23:10:15 INFO: msgN\n", 31 bytes: three fit into 100 bytes
+	l, _ := newTestLogger(t, WithOutputMode(FileOnly), WithFile(filepath.Join(dir, "app.log")),
+		WithOmitSource(true), WithMaxSize(100), WithLocation(time.UTC))
+	defer l.Close()
+	l.setClock(clock.now)
+	l.SetSizeResync(1)
+
+	for i := 1; i <= 3; i++ {
+		l.Info("msg%d", i)
+	}
+	path := l.CurrentFilePath()
+	if err := os.Truncate(path, 0); err != nil { // e.g. an operator running `> app.log`
+		t.Fatal(err)
+	}
+
+	for i := 4; i <= 6; i++ {
+		l.Info("msg%d", i)
+	}
+	if l.CurrentFilePath() != path {
+		t.Fatal("rotated by the stale size although the truncated file has room")
+	}
+	clock.add(time.Second)
+	l.Info("msg7")
+	if l.CurrentFilePath() == path {
+		t.Fatal("no rotation at the real threshold")
+	}
+	if got := readFile(t, path); strings.Count(got, "\n") != 3 || !strings.HasSuffix(got, "INFO: msg6\n") {
+		t.Errorf("truncated file = %q", got)
+	}
+}
+
+func TestCloseWithTimeout(t *testing.T) {
+	l, _ := newTestLogger(t)
+	w := newBlockingWriter()
+	defer close(w.release) // lets the background Close finish
+	l.AddSink(w, LevelInfo, 16, OverflowBlock)
+
+	for i := 0; i < 5; i++ {
+		l.Info("msg %d", i) // the first line wedges the sink, the rest stay queued
+	}
+
+	start := time.Now()
+	err := l.CloseWithTimeout(50 * time.Millisecond)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("CloseWithTimeout took %v", elapsed)
+	}
+	if err == nil || !strings.Contains(err.Error(), "close timed out after 50ms") {
+		t.Fatalf("err = %v", err)
+	}
+	if !strings.Contains(err.Error(), "entries unflushed") {
+		t.Errorf("err = %v, want the number of unflushed entries", err)
+	}
+
+	fast, _ := newTestLogger(t)
+	if err := fast.CloseWithTimeout(time.Second); err != nil {
+		t.Errorf("CloseWithTimeout of an idle logger: %v", err)
+	}
+}
+
+func TestSourceLevels(t *testing.T) {
+	l, buf := newTestLogger(t, WithConsoleLevel(LevelDebug), WithIncludeCaller(false))
+	// Lines written through l.Writer by fmt.Fprint report fmt's print.go as their source,
+	// standing in for a noisy library
+	noisy := l.Writer(LevelDebug)
+	l.SetSourceLevels(map[string]LogLevel{"print.go": LevelWarn})
+
+	fmt.Fprint(noisy, "dropped debug")
+	l.Debug("kept debug")
+	fmt.Fprint(l.Writer(LevelWarn), "kept warn")
+
+	out := buf.String()
+	if strings.Contains(out, "dropped debug") {
+		t.Errorf("debug line from the silenced file was written:\n%s", out)
+	}
+	if !strings.Contains(out, " - kept debug") || !strings.Contains(out, " - kept warn") {
+		t.Errorf("output = %q", out)
+	}
+	if strings.Contains(out, "print.go") {
+		t.Errorf("source rendered although SetIncludeCaller is off: %q", out)
+	}
+
+	buf.Reset()
+	l.SetSourceLevels(nil)
+	fmt.Fprint(noisy, "debug again")
+	if !strings.Contains(buf.String(), "debug again") {
+		t.Error("SetSourceLevels(nil) did not remove the per-file level")
+	}
+}
+
+func TestSourceLevelsLowerThreshold(t *testing.T) {
+	var file bytes.Buffer
+	l, _ := newTestLogger(t, WithOutputMode(FileOnly), WithFileLevel(LevelInfo), WithWriter(&file))
+	// The per-file level replaces the file level, so it can lower it as well
+	l.SetSourceLevels(map[string]LogLevel{"logger_test.go": LevelDebug})
+
+	l.Debug("debug from this file")
+	l.Trace("trace from this file")
+	fmt.Fprint(l.Writer(LevelDebug), "debug from print.go")
+	slog.New(NewSlogHandler(l)).Debug("debug through slog")
+
+	out := file.String()
+	if !strings.Contains(out, " - debug from this file") || !strings.Contains(out, " - debug through slog") {
+		t.Errorf("debug lines from the verbose file were dropped: %q", out)
+	}
+	if strings.Contains(out, "trace from this file") || strings.Contains(out, "debug from print.go") {
+		t.Errorf("lines below the per-file or file level were written: %q", out)
+	}
+	if !l.IsEnabled(LevelDebug) || l.IsEnabled(LevelTrace) {
+		t.Error("IsEnabled ignores the per-file level")
+	}
+
+	file.Reset()
+	l.SetSourceLevels(map[string]LogLevel{"logger_test.go": LevelError})
+	slog.New(NewSlogHandler(l)).Warn("warn through slog")
+	if file.Len() != 0 {
+		t.Errorf("slog handler ignored the per-file level: %q", file.String())
+	}
+}
+
+func TestLogLevelArgument(t *testing.T) {
+	l, buf := newTestLogger(t, WithConsoleLevel(LevelTrace))
+	for _, level := range []LogLevel{LevelTrace, LevelDebug, LevelInfo, LevelWarn, LevelError} {
+		buf.Reset()
+		line := currentLine() + 1
+		l.Log(level, "at %v", level)
+		want := fmt.Sprintf("%v: logger_test.go:%d - at %v\n", level, line, level)
+		if !strings.HasSuffix(buf.String(), want) {
+			t.Errorf("Log(%v) = %q, want suffix %q", level, buf, want)
+		}
+	}
+
+	resetGlobal(t)
+	defer ReplaceGlobal(l)()
+	buf.Reset()
+	line := currentLine() + 1
+	Log(LevelWarn, "global")
+	if want := fmt.Sprintf("WARN: logger_test.go:%d - global\n", line); !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("package-level Log = %q, want suffix %q", buf, want)
+	}
+}
+
+func TestLogfmtQuoting(t *testing.T) {
+	clock := newTestClock()
+	l, buf := newTestLogger(t, WithFormat(FormatLogfmt), WithLocation(time.UTC))
+	l.setClock(clock.now)
+
+	line := currentLine() + 1
+	l.WithFields(Fields{"plain": "ok", "spaced": "a b", "eq": "k=v", "quote": `say "hi"`, "empty": "", "bad key": 1}).Warn("disk full")
+	want := fmt.Sprintf(`time=2036-02-02T23:10:15Z level=warn source=logger_test.go:%d msg="disk full" `+
+		`bad_key=1 empty="" eq="k=v" plain=ok quote="say \"hi\"" spaced="a b"`+"\n", line)
+	if buf.String() != want {
+		t.Errorf("line =\n%q\nwant\n%q", buf, want)
+	}
+
+	buf.Reset()
+	l.SetSliceFieldSeparator(" | ")
+	l.WithFields(Fields{"ids": []int{1, 2}}).Info("ok")
+	if !strings.HasSuffix(buf.String(), ` msg=ok ids="1 | 2"`+"\n") {
+		t.Errorf("slice field = %q", buf)
+	}
+}
+
+func TestOnRotate(t *testing.T) {
+	dir := t.TempDir()
+	clock := newTestClock()
+	l, _ := newTestLogger(t, WithOutputMode(FileOnly), WithFile(filepath.Join(dir, "app.log")), WithMaxLines(1), WithFileLevel(LevelInfo))
+	defer l.Close()
+	l.setClock(clock.now)
+
+	var got []rotation
+	l.OnRotate(func(oldPath, newPath string) {
+		got = append(got, rotation{oldPath, newPath})
+		// Logging from the callback does not deadlock; the line is below the file level,
+		// so it does not rotate the one-line files again
+		l.Debug("rotated to %s", filepath.Base(newPath))
+	})
+
+	l.Info("first")
+	first := l.CurrentFilePath()
+	clock.add(time.Second)
+	l.Info("second") // rotates by line count
+	second := l.CurrentFilePath()
+	clock.add(time.Second)
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []rotation{{first, second}, {second, l.CurrentFilePath()}}
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("callback got %v, want %v", got, want)
+	}
+}
+
+func TestRotationFailureKeepsLine(t *testing.T) {
+	dir := t.TempDir()
+	l, _ := newTestLogger(t, WithOutputMode(FileOnly), WithFile(filepath.Join(dir, "app.log")), WithMaxLines(1))
+	defer l.Close()
+	var errs []error
+	l.OnError(func(err error) { errs = append(errs, err) })
+
+	l.Info("first")
+	first := l.CurrentFilePath()
+
+	// A regular file in place of the directory makes opening the next file fail
+	blocker := filepath.Join(dir, "blocker")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	l.mu.Lock()
+	basePath := l.basePath
+	l.basePath = filepath.Join(blocker, "app.log")
+	l.mu.Unlock()
+
+	l.Info("second")
+	if len(errs) != 1 {
+		t.Fatalf("rotation errors = %v, want one", errs)
+	}
+	if got := l.CurrentFilePath(); got != first {
+		t.Fatalf("current file = %s after a failed rotation, want %s", got, first)
+	}
+
+	l.mu.Lock()
+	l.basePath = basePath
+	l.mu.Unlock()
+	l.Info("third")
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readFile(t, first); !strings.Contains(got, " - first\n") || !strings.HasSuffix(got, " - second\n") {
+		t.Errorf("old file = %q, want the line written during the failed rotation", got)
+	}
+	var all string
+	for _, path := range logFiles(t, dir) {
+		all += readFile(t, path)
+	}
+	for _, msg := range []string{"first", "second", "third"} {
+		if n := strings.Count(all, " - "+msg+"\n"); n != 1 {
+			t.Errorf("%q written %d times across files, want once", msg, n)
+		}
+	}
+}
+
+func TestCollisionLimit(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "app.log")
+	now := newTestClock().now()
+	taken := pathWithSuffix(base, timestampSuffix(now))
+	for _, path := range []string{taken, pathWithSuffix(base, timestampSuffix(now)+"_01")} {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path, err := uniqueLogPath(base, now, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := pathWithSuffix(base, timestampSuffix(now)+"_02"); path != want {
+		t.Errorf("path = %s, want %s", path, want)
+	}
+
+	// Past the limit the counter is skipped for a nanosecond plus random suffix
+	start := time.Now()
+	fallback := regexp.MustCompile(`^app_` + regexp.QuoteMeta(timestampSuffix(now)) + `_\d{9}_[0-9a-f]{6}\.log$`)
+	seen := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		path, err := uniqueLogPath(base, now, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !fallback.MatchString(filepath.Base(path)) {
+			t.Fatalf("fallback path %s", path)
+		}
+		if seen[path] {
+			t.Fatalf("fallback path %s returned twice", path)
+		}
+		seen[path] = true
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("20 fallback paths took %v", elapsed)
+	}
+
+	l, _ := newTestLogger(t, WithOutputMode(FileOnly), WithFile(filepath.Join(t.TempDir(), "app.log")))
+	defer l.Close()
+	l.SetCollisionLimit(0)
+	l.mu.Lock()
+	got := l.collisionLimitLocked()
+	l.mu.Unlock()
+	if got != defaultCollisionLimit {
+		t.Errorf("limit after SetCollisionLimit(0) = %d, want %d", got, defaultCollisionLimit)
+	}
+}
+
+func TestFileHandle(t *testing.T) {
+	dir := t.TempDir()
+	f, err := os.OpenFile(filepath.Join(dir, "app.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	header := strings.Repeat("x", 99) + "\n"
+	if _, err := f.WriteString(header); err != nil {
+		t.Fatal(err)
+	}
+
+	l, _ := newTestLogger(t, WithOutputMode(FileOnly), WithFileHandle(f, false), WithMaxSize(200))
+	l.Info("fits")
+	l.mu.Lock()
+	size := l.currentSize
+	l.mu.Unlock()
+	if info, err := f.Stat(); err != nil || size != info.Size() || size <= int64(len(header)) {
+		t.Errorf("tracked size = %d, want the file size including the header (%v)", size, err)
+	}
+	if got := l.CurrentFilePath(); got != f.Name() {
+		t.Errorf("current file = %s, want the handle's %s", got, f.Name())
+	}
+
+	// The size started at the 100 bytes already in the file, so the second line rotates
+	l.Info("rotates")
+	rotated := l.CurrentFilePath()
+	if rotated == f.Name() || filepath.Dir(rotated) != dir {
+		t.Errorf("rotated to %s, want a new file in %s", rotated, dir)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A borrowed handle survives rotation and Close
+	if _, err := f.WriteString("caller\n"); err != nil {
+		t.Fatalf("handle closed by the logger: %v", err)
+	}
+	got := readFile(t, f.Name())
+	if !strings.HasPrefix(got, header) || !strings.Contains(got, " - fits\n") || strings.Contains(got, "rotates") || !strings.HasSuffix(got, "caller\n") {
+		t.Errorf("handle file = %q", got)
+	}
+	if got := readFile(t, rotated); !strings.HasSuffix(got, " - rotates\n") {
+		t.Errorf("rotated file = %q", got)
+	}
+
+	owned, err := os.CreateTemp(dir, "owned")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, _ = newTestLogger(t, WithOutputMode(FileOnly), WithFileHandle(owned, true))
+	l.Info("owned")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := owned.WriteString("after close\n"); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("write to an owned handle after Close: %v, want os.ErrClosed", err)
+	}
+}
+
+func TestHasFileWriter(t *testing.T) {
+	resetGlobal(t)
+	if HasFileWriter() || GetOutputMode() != ConsoleOnly {
+		t.Errorf("without a logger: HasFileWriter() = %v, GetOutputMode() = %v", HasFileWriter(), GetOutputMode())
+	}
+
+	dir := t.TempDir()
+	for _, tt := range []struct {
+		mode OutputMode
+		want bool
+	}{
+		{ConsoleOnly, false},
+		{FileOnly, true},
+		{Both, true},
+	} {
+		l, _ := newTestLogger(t, WithOutputMode(tt.mode), WithFile(filepath.Join(dir, "app.log")))
+		if got := l.HasFileWriter(); got != tt.want {
+			t.Errorf("%v: HasFileWriter() = %v, want %v", tt.mode, got, tt.want)
+		}
+		if got := l.GetOutputMode(); got != tt.mode {
+			t.Errorf("GetOutputMode() = %v, want %v", got, tt.mode)
+		}
+		_ = l.Close()
+	}
+
+	if err := Init(Both, LevelInfo, LevelInfo, filepath.Join(dir, "global.log"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if !HasFileWriter() || GetOutputMode() != Both {
+		t.Errorf("global: HasFileWriter() = %v, GetOutputMode() = %v", HasFileWriter(), GetOutputMode())
+	}
+}
+
+func TestTimePrecision(t *testing.T) {
+	clock := newTestClock()
+	l, buf := newTestLogger(t, WithTimePrecision(PrecisionMicro))
+	l.setClock(clock.now)
+
+	l.Info("first")
+	clock.add(time.Microsecond)
+	l.Info("second")
+	lines := strings.Split(buf.String(), "\n")
+	if !strings.HasPrefix(lines[0], "2036/02/02 23:10:15.000000 INFO: ") || !strings.HasPrefix(lines[1], "2036/02/02 23:10:15.000001 INFO: ") {
+		t.Errorf("microsecond lines = %q", lines[:2])
+	}
+
+	buf.Reset()
+	l.SetFormat(FormatJSON)
+	l.SetTimePrecision(PrecisionNano)
+	clock.add(time.Nanosecond)
+	l.Info("json")
+	if !strings.Contains(buf.String(), `"time":"2036-02-02T23:10:15.000001001Z"`) {
+		t.Errorf("nanosecond JSON line = %q", buf)
+	}
+
+	buf.Reset()
+	l.SetFormat(FormatText)
+	l.SetTimePrecision(PrecisionSecond)
+	l.Info("default")
+	if !strings.HasPrefix(buf.String(), "2036/02/02 23:10:15 INFO: ") {
+		t.Errorf("second precision line = %q", buf)
+	}
+}
+
+func TestCallerPathMode(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	short := filepath.Base(filepath.Dir(file)) + "/logger_test.go"
+	if mainModulePath() == "github.com/ZeRg0912/logger" {
+		short = "logger_test.go" // the package is the module root
+	}
+
+	for _, tt := range []struct {
+		mode CallerPathMode
+		want string
+	}{
+		{CallerPathBase, "logger_test.go"},
+		{CallerPathShort, short},
+		{CallerPathFull, file},
+	} {
+		l, buf := newTestLogger(t, WithCallerPathMode(tt.mode))
+		line := currentLine() + 1
+		l.Info("here")
+		if want := fmt.Sprintf("INFO: %s:%d - here\n", tt.want, line); !strings.HasSuffix(buf.String(), want) {
+			t.Errorf("mode %d: line = %q, want suffix %q", tt.mode, buf, want)
+		}
+	}
+
+	for name, want := range map[string]string{
+		"github.com/org/app/internal/api.(*Server).handle": "github.com/org/app/internal/api",
+		"github.com/org/app.main":                          "github.com/org/app",
+		"main.main.func1":                                  "main",
+	} {
+		if got := funcPackagePath(name); got != want {
+			t.Errorf("funcPackagePath(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// panicStringer panics when formatted.
+type panicStringer struct{}
+
+func (panicStringer) String() string { panic("boom") }
+
+// panicFormatter is a Formatter that always panics.
+type panicFormatter struct{}
+
+func (panicFormatter) Format(LogLevel, time.Time, string, string, Fields) []byte {
+	panic("bad formatter")
+}
+
+func TestPanickingArguments(t *testing.T) {
+	l, buf := newTestLogger(t)
+
+	l.Info("value: %v", panicStringer{})
+	l.Info("still logging")
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], " - value: %!v(PANIC=String method: boom)") || !strings.HasSuffix(lines[1], " - still logging") {
+		t.Errorf("lines = %q", lines)
+	}
+
+	buf.Reset()
+	l.SetFormatter(panicFormatter{})
+	l.Info("rendered")
+	if got := buf.String(); !strings.HasSuffix(got, " - rendered %!PANIC(bad formatter)\n") {
+		t.Errorf("placeholder line = %q", got)
+	}
+
+	// The lock was released, so the logger keeps working
+	buf.Reset()
+	l.SetFormatter(nil)
+	l.Info("after")
+	if !strings.HasSuffix(buf.String(), " - after\n") {
+		t.Errorf("line after the panic = %q", buf)
+	}
+}
+
+func TestContextFields(t *testing.T) {
+	l, buf := newTestLogger(t)
+	ctx := ContextWithFields(context.Background(), Fields{"trace_id": "abc123", "user": "ann"})
+	ctx = ContextWithFields(ctx, Fields{"user": "bob"})
+
+	l.WithContext(ctx).Info("handled")
+	if got := buf.String(); !strings.Contains(got, " - handled") || !strings.Contains(got, "trace_id=abc123") ||
+		!strings.Contains(got, "user=bob") || strings.Contains(got, "ann") {
+		t.Errorf("line = %q, want trace_id and the newer user", got)
+	}
+	if l.WithContext(context.Background()) != l {
+		t.Error("WithContext without fields returned a new logger")
+	}
+
+	resetGlobal(t)
+	if FromContext(ctx) != nil {
+		t.Error("FromContext without a global logger is not nil")
+	}
+	FromContext(ctx).Info("no-op") // nil loggers do not panic
+
+	ReplaceGlobal(l)
+	buf.Reset()
+	FromContext(ctx).Warn("global")
+	if got := buf.String(); !strings.Contains(got, " - global") || !strings.Contains(got, "trace_id=abc123") {
+		t.Errorf("global line = %q", got)
+	}
+}
+
+func TestLogString(t *testing.T) {
+	dir := t.TempDir()
+	l, _ := newTestLogger(t, WithOutputMode(FileOnly), WithFile(filepath.Join(dir, "app.log")), WithFileLevel(LevelInfo))
+	defer l.Close()
+
+	line := currentLine() + 1
+	msg := l.ErrorString("load config %s: %v", "app.yaml", errDiskFull)
+	if msg != "load config app.yaml: "+errDiskFull.Error() {
+		t.Errorf("ErrorString() = %q", msg)
+	}
+	if got := l.LogString(LevelDebug, "filtered %d", 1); got != "filtered 1" {
+		t.Errorf("LogString() below the file level = %q", got)
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := readFile(t, l.CurrentFilePath())
+	if want := fmt.Sprintf("ERROR: logger_test.go:%d - %s\n", line, msg); !strings.HasSuffix(got, want) || strings.Count(got, "\n") != 1 {
+		t.Errorf("file = %q, want the single line %q", got, want)
+	}
+}
+
+func TestColorEnvironment(t *testing.T) {
+	l, buf := newTestLogger(t, WithColorMode(ColorAuto))
+	for _, tt := range []struct {
+		noColor, forceColor string
+		want                bool
+	}{
+		{"", "", false}, // a buffer is not a terminal
+		{"", "1", true},
+		{"", "0", false},
+		{"", "false", false},
+		{"1", "1", false}, // NO_COLOR wins
+	} {
+		t.Setenv("NO_COLOR", tt.noColor)
+		t.Setenv("FORCE_COLOR", tt.forceColor)
+		buf.Reset()
+		l.Info("line")
+		if got := strings.Contains(buf.String(), ansiGreen); got != tt.want {
+			t.Errorf("NO_COLOR=%q FORCE_COLOR=%q: colored = %v, want %v: %q", tt.noColor, tt.forceColor, got, tt.want, buf)
+		}
+	}
+
+	// ColorAlways ignores the environment
+	t.Setenv("NO_COLOR", "1")
+	l.SetColorMode(ColorAlways)
+	buf.Reset()
+	l.Info("line")
+	if !strings.Contains(buf.String(), ansiGreen) {
+		t.Errorf("ColorAlways with NO_COLOR: %q", buf)
+	}
+}
+
+func TestStderrLevel(t *testing.T) {
+	l, _ := newTestLogger(t)
+	var stdout, stderr bytes.Buffer
+	l.SetConsoleOutput(&stdout, &stderr)
+
+	l.Warn("default warn")
+	l.Error("default error")
+	if !strings.Contains(stdout.String(), " - default warn") || !strings.Contains(stderr.String(), " - default error") || strings.Contains(stderr.String(), "warn") {
+		t.Errorf("default split: stdout %q, stderr %q", &stdout, &stderr)
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	l.SetStderrLevel(LevelWarn)
+	l.Info("info")
+	l.Warn("warn")
+	l.Error("error")
+	if got := stdout.String(); !strings.HasSuffix(got, " - info\n") || strings.Count(got, "\n") != 1 {
+		t.Errorf("stdout = %q, want the info line only", got)
+	}
+	if got := stderr.String(); !strings.Contains(got, "WARN: ") || !strings.Contains(got, "ERROR: ") || strings.Contains(got, "INFO") {
+		t.Errorf("stderr = %q, want the warn and error lines", got)
+	}
+}
+
+func TestBatchingTimedFlush(t *testing.T) {
+	dir := t.TempDir()
+	l, _ := newTestLogger(t, WithOutputMode(FileOnly), WithFile(filepath.Join(dir, "app.log")))
+	defer l.Close()
+	l.SetBatching(64<<10, 100*time.Millisecond)
+
+	l.Info("pending")
+	path := l.CurrentFilePath()
+	l.mu.Lock()
+	size := l.currentSize
+	l.mu.Unlock()
+	if got := readFile(t, path); got != "" {
+		t.Fatalf("line written before the flush interval: %q", got)
+	}
+	// Size accounting counts buffered bytes, so limits hold before the flush
+	if size == 0 {
+		t.Error("buffered line is not counted in the file size")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.HasSuffix(readFile(t, path), " - pending\n") {
+		if time.Now().After(deadline) {
+			t.Fatal("timed flush did not write the line")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := int64(len(readFile(t, path))); got != size {
+		t.Errorf("file size %d, counted %d", got, size)
+	}
+
+	l.SetBatching(64<<10, time.Hour)
+	l.Info("before rotation")
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("after rotation")
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, path); !strings.HasSuffix(got, " - before rotation\n") {
+		t.Errorf("old file = %q, want the batch flushed before the swap", got)
+	}
+	if got := readFile(t, l.CurrentFilePath()); !strings.HasSuffix(got, " - after rotation\n") || strings.Contains(got, "before") {
+		t.Errorf("new file = %q", got)
+	}
+}
+
+// BenchmarkBatchedFile compares writing every line to a file with one write per
+// line against batching, which needs a write syscall per 64 KiB only.
+func BenchmarkBatchedFile(b *testing.B) {
+	for _, size := range []int{0, 64 << 10} {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			l, _ := newTestLogger(b, WithOutputMode(FileOnly), WithFile(filepath.Join(b.TempDir(), "app.log")))
+			l.SetBatching(size, time.Second)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				l.Info("line %d", i)
+			}
+			b.StopTimer()
+			_ = l.Close()
+		})
+	}
+}
+
+func TestTimer(t *testing.T) {
+	l, buf := newTestLogger(t)
+
+	var line int
+	func() {
+		line = currentLine() + 1
+		defer l.Timer(LevelInfo, "request handled")()
+		time.Sleep(10 * time.Millisecond)
+	}()
+
+	got := strings.TrimSuffix(buf.String(), "\n")
+	if prefix := fmt.Sprintf("INFO: logger_test.go:%d - request handled duration=", line); !strings.Contains(got, prefix) {
+		t.Fatalf("line = %q, want %q", got, prefix)
+	}
+	elapsed, err := time.ParseDuration(got[strings.LastIndex(got, "=")+1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed < 10*time.Millisecond || elapsed > 5*time.Second {
+		t.Errorf("duration = %v, want about 10ms", elapsed)
+	}
+
+	resetGlobal(t)
+	Timer(LevelInfo, "no logger")() // no-op without a global logger
+}
+
+// TestCloseWhileLogging is meant for -race: log calls from many goroutines
+// during and after Close must neither panic nor race, and are discarded afterwards.
+func TestCloseWhileLogging(t *testing.T) {
+	dir := t.TempDir()
+	l, _ := newTestLogger(t, WithOutputMode(FileOnly), WithFile(filepath.Join(dir, "app.log")))
+	l.SetAsync(64, OverflowBlock)
+	l.SetBatching(4096, time.Millisecond)
+	path := l.CurrentFilePath()
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			child := l.WithFields(Fields{"g": g})
+			w := l.Writer(LevelInfo)
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				l.Info("line %d", i)
+				child.Warn("child %d", i)
+				fmt.Fprintf(w, "writer %d\n", i)
+				l.Timer(LevelDebug, "timed")()
+			}
+		}(g)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	var closers sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		closers.Add(1)
+		go func() {
+			defer closers.Done()
+			if err := l.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	closers.Wait()
+	written := readFile(t, path)
+	time.Sleep(10 * time.Millisecond) // keep logging after Close
+	close(stop)
+	wg.Wait()
+
+	if got := readFile(t, path); got != written {
+		t.Errorf("%d bytes written after Close", len(got)-len(written))
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 1 {
+		t.Errorf("files after Close: %v (%v), want only %s", entries, err, filepath.Base(path))
+	}
+	if written == "" || !strings.HasSuffix(written, "\n") {
+		t.Fatalf("file ends with a partial line: %q", written[max(0, len(written)-80):])
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(written, "\n"), "\n") {
+		if !strings.Contains(line, "INFO: ") && !strings.Contains(line, "WARN: ") && !strings.Contains(line, "DEBUG: ") {
+			t.Fatalf("broken line %q", line)
+		}
+	}
+}
+
+func TestLevelWidth(t *testing.T) {
+	l, buf := newTestLogger(t, WithConsoleLevel(LevelDebug), WithLevelWidth(5))
+	l.Debug("d")
+	l.Info("i")
+	l.Warn("w")
+	l.Error("e")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	for i, token := range []string{" DEBUG: ", " INFO : ", " WARN : ", " ERROR: "} {
+		if !strings.Contains(lines[i], token) {
+			t.Errorf("line %q, want level token %q", lines[i], token)
+		}
+		if col := strings.Index(lines[i], "logger_test.go"); col != strings.Index(lines[0], "logger_test.go") {
+			t.Errorf("source column of %q is %d, not aligned", lines[i], col)
+		}
+	}
+
+	buf.Reset()
+	l.SetFormat(FormatJSON)
+	l.Info("json")
+	if !strings.Contains(buf.String(), `"level":"INFO"`) {
+		t.Errorf("JSON level is padded: %q", buf)
+	}
+
+	buf.Reset()
+	l.SetFormat(FormatText)
+	l.SetLevelWidth(0)
+	l.Info("default")
+	if !strings.Contains(buf.String(), " INFO: ") {
+		t.Errorf("unpadded line = %q", buf)
+	}
+}
+
+func TestOpenCurrentFile(t *testing.T) {
+	dir := t.TempDir()
+	clock := newTestClock()
+	l, _ := newTestLogger(t, WithOutputMode(FileOnly), WithFile(filepath.Join(dir, "app.log")))
+	defer l.Close()
+	l.setClock(clock.now)
+	l.SetBatching(4096, time.Hour) // the pending batch is written first
+
+	line := currentLine() + 1
+	l.Info("first")
+	l.Warn("second")
+	r, err := l.OpenCurrentFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	l.Info("after open") // appended to the same file, after the expected content
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("2036/02/02 23:10:15 INFO: logger_test.go:%d - first\n2036/02/02 23:10:15 WARN: logger_test.go:%d - second\n", line, line+1)
+	if got := string(data); !strings.HasPrefix(got, want) {
+		t.Errorf("read %q, want it to start with %q", got, want)
+	}
+
+	console, _ := newTestLogger(t)
+	if _, err := console.OpenCurrentFile(); !errors.Is(err, ErrNoLogFile) {
+		t.Errorf("console-only OpenCurrentFile() error = %v, want ErrNoLogFile", err)
+	}
+}
+
+func TestConsoleWarn(t *testing.T) {
+	resetGlobal(t)
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, _ := newTestLogger(t, WithOutputMode(Both), WithFile(path))
+	defer l.Close()
+	var stdout, stderr bytes.Buffer
+	l.SetConsoleOutput(&stdout, &stderr)
+	defer ReplaceGlobal(l)()
+
+	line := currentLine() + 1
+	ConsoleWarn("disk almost full: %d%%", 93)
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := stderr.String(); got != "Warning: disk almost full: 93%\n" {
+		t.Errorf("stderr = %q", got)
+	}
+	want := fmt.Sprintf("WARN: logger_test.go:%d - disk almost full: 93%%\n", line)
+	if got := readFile(t, l.CurrentFilePath()); !strings.HasSuffix(got, want) {
+		t.Errorf("file = %q, want suffix %q", got, want)
+	}
+}
+
+func TestConsoleHelpersRespectLevel(t *testing.T) {
+	resetGlobal(t)
+	l, buf := newTestLogger(t, WithConsoleLevel(LevelWarn))
+	defer ReplaceGlobal(l)()
+
+	// Default: the helpers print regardless of the elevated threshold
+	ConsoleInfo("i")
+	ConsoleSuccess("s")
+	if want := "Info: i\nSuccess: s\n"; buf.String() != want {
+		t.Errorf("default output = %q, want %q", buf, want)
+	}
+
+	buf.Reset()
+	l.SetConsoleHelpersRespectLevel(true)
+	ConsoleInfo("i")
+	ConsoleSuccess("s")
+	ConsoleWarn("w")
+	ConsoleError("e")
+	if want := "Warning: w\nError: e\n"; buf.String() != want {
+		t.Errorf("output respecting the level = %q, want %q", buf, want)
+	}
+
+	buf.Reset()
+	l.SetConsoleLevel(LevelInfo)
+	ConsoleInfo("i")
+	if want := "Info: i\n"; buf.String() != want {
+		t.Errorf("output at the Info threshold = %q, want %q", buf, want)
+	}
+}
+
+// stackError is an error with a richer %+v form, like errors with stack traces.
+type stackError struct{ msg string }
+
+func (e stackError) Error() string { return e.msg }
+
+func (e stackError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		fmt.Fprintf(s, "%s\nmain.load\n\tmain.go:12", e.msg)
+		return
+	}
+	fmt.Fprint(s, e.msg)
+}
+
+func TestWithError(t *testing.T) {
+	l, buf := newTestLogger(t, WithFormat(FormatJSON))
+	err := fmt.Errorf("load config: %w", fmt.Errorf("open app.yaml: %w", errDiskFull))
+
+	l.WithError(err).Error("startup failed")
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := record["error"], "load config: open app.yaml: "+errDiskFull.Error(); got != want {
+		t.Errorf("error field = %v, want the whole chain %q", got, want)
+	}
+	if _, ok := record["error_detail"]; ok {
+		t.Errorf("error_detail for a plain error: %v", record)
+	}
+
+	buf.Reset()
+	l.SetFormat(FormatText)
+	l.WithError(stackError{"boom"}).Error("failed")
+	if got := buf.String(); !strings.Contains(got, " - failed") || !strings.Contains(got, "error=boom") || !strings.Contains(got, "main.go:12") {
+		t.Errorf("text line = %q, want the error and its detail", got)
+	}
+
+	if l.WithError(nil) != l {
+		t.Error("WithError(nil) returned a new logger")
+	}
+}
+
+func TestFieldOrder(t *testing.T) {
+	fields := Fields{"zone": "eu", "user": "ann", "attempt": 3, "method": "GET", "b": true, "id": 42}
+	for _, tt := range []struct {
+		format Format
+		want   string
+	}{
+		{FormatText, " INFO: ordered attempt=3 b=true id=42 method=GET user=ann zone=eu\n"},
+		{FormatLogfmt, " msg=ordered attempt=3 b=true id=42 method=GET user=ann zone=eu\n"},
+	} {
+		l, buf := newTestLogger(t, WithFormat(tt.format), WithOmitSource(true))
+		child := l.WithFields(fields)
+		for i := 0; i < 20; i++ { // map iteration order differs between runs
+			buf.Reset()
+			child.Info("ordered")
+			if !strings.HasSuffix(buf.String(), tt.want) {
+				t.Fatalf("line %d = %q, want suffix %q", i, buf, tt.want)
+			}
+		}
+	}
+}
+
+func TestOmitSource(t *testing.T) {
+	clock := newTestClock()
+	l, buf := newTestLogger(t, WithLocation(time.UTC))
+	l.setClock(clock.now)
+
+	l.SetIncludeCaller(false)
+	l.Info("message")
+	if want := "2036/02/02 23:10:15 INFO: ??? - message\n"; buf.String() != want {
+		t.Errorf("without caller lookup = %q, want %q", buf, want)
+	}
+
+	buf.Reset()
+	l.SetOmitSource(true)
+	l.Info("message")
+	if want := "2036/02/02 23:10:15 INFO: message\n"; buf.String() != want {
+		t.Errorf("without source = %q, want %q", buf, want)
+	}
+
+	buf.Reset()
+	l.SetIncludeCaller(true) // omitting the source wins over the caller lookup
+	l.SetFormat(FormatJSON)
+	l.Info("message")
+	l.SetFormat(FormatLogfmt)
+	l.Info("message")
+	if got := buf.String(); strings.Contains(got, "source") || strings.Contains(got, "logger_test.go") {
+		t.Errorf("structured lines carry a source: %q", got)
+	}
+
+	buf.Reset()
+	l.SetFormat(FormatText)
+	l.SetOmitSource(false)
+	l.Info("message")
+	if !strings.Contains(buf.String(), " INFO: logger_test.go:") {
+		t.Errorf("source not restored: %q", buf)
+	}
+}
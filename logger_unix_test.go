@@ -0,0 +1,136 @@
+//go:build unix
+
+package logger
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFilePermissions(t *testing.T) {
+	dir := t.TempDir()
+	l := newFileLogger(t, dir)
+	l.SetFilePermissions(0600, 0700)
+
+	l.Info("secret")
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range logFiles(t, dir) {
+		assertMode(t, path, 0600)
+	}
+}
+
+// assertMode fails t unless the permission bits of path equal want.
+func assertMode(t *testing.T, path string, want os.FileMode) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != want {
+		t.Errorf("%s mode = %v, want %v", path, got, want)
+	}
+}
+
+func TestSymlinkCurrent(t *testing.T) {
+	dir := t.TempDir()
+	link := filepath.Join(dir, "app.log")
+	l := newFileLogger(t, dir)
+	l.SetSymlinkCurrent(true)
+
+	l.Info("old")
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	l.Info("new")
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	target, err := os.Readlink(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target != filepath.Base(l.CurrentFilePath()) {
+		t.Errorf("link points at %s, want %s", target, filepath.Base(l.CurrentFilePath()))
+	}
+	if got := readFile(t, link); !strings.HasSuffix(got, " - new\n") || strings.Contains(got, " - old") {
+		t.Errorf("read through the link: %q", got)
+	}
+}
+
+func TestEmojiLabels(t *testing.T) {
+	// /dev/null is a character device, so it passes the terminal check
+	tty, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer tty.Close()
+
+	l, _ := newTestLogger(t)
+	t.Setenv("NO_EMOJI", "")
+	if got := l.consoleLabel(tty, "❌", "Error:"); got != "Error:" {
+		t.Errorf("label with emoji disabled = %q", got)
+	}
+	l.SetEmoji(true)
+	if got := l.consoleLabel(tty, "❌", "Error:"); got != "❌ Error:" {
+		t.Errorf("label on a terminal = %q", got)
+	}
+	t.Setenv("NO_EMOJI", "1")
+	if got := l.consoleLabel(tty, "✅", "Success:"); got != "Success:" {
+		t.Errorf("label with NO_EMOJI = %q", got)
+	}
+}
+
+func TestColorOnTerminal(t *testing.T) {
+	// /dev/null is a character device, so it passes the terminal check
+	tty, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer tty.Close()
+
+	t.Setenv("FORCE_COLOR", "")
+	t.Setenv("NO_COLOR", "")
+	if !autoColor(tty) {
+		t.Error("no colors on a terminal")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if autoColor(tty) {
+		t.Error("colors on a terminal with NO_COLOR")
+	}
+}
+
+func TestOpenCurrentFileSurvivesDelete(t *testing.T) {
+	dir := t.TempDir()
+	l, _ := newTestLogger(t, WithOutputMode(FileOnly), WithFile(filepath.Join(dir, "app.log")))
+	defer l.Close()
+
+	l.Info("shipped")
+	path := l.CurrentFilePath()
+	r, err := l.OpenCurrentFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	// Rotation and retention may delete the file while the shipper reads it
+	if err := l.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(data), " - shipped\n") {
+		t.Errorf("read %q after the file was deleted", data)
+	}
+}
@@ -0,0 +1,40 @@
+// Package logtest provides helpers for testing code that logs through the logger package.
+//
+//	func TestHandler(t *testing.T) {
+//		_, buf, restore := logtest.Capture()
+//		defer restore()
+//
+//		handle()
+//
+//		if !strings.Contains(buf.String(), "WARN: ") {
+//			t.Errorf("no warning logged:\n%s", buf)
+//		}
+//	}
+package logtest
+
+import (
+	"bytes"
+
+	"github.com/ZeRg0912/logger"
+)
+
+// New returns an independent logger writing lines of all levels to the returned buffer
+// in text format. Nothing is written to the console or to files.
+// The buffer must not be read while other goroutines are still logging.
+func New() (*logger.Logger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	l, err := logger.NewWithWriter(logger.FileOnly, logger.LevelTrace, logger.LevelTrace, buf)
+	if err != nil {
+		// NewWithWriter fails only for a nil writer
+		panic(err)
+	}
+	return l, buf
+}
+
+// Capture installs a logger created by New as the global logger, so package-level
+// calls such as logger.Warn end up in the returned buffer. restore reinstalls
+// the previous global logger; it must be called when the test finishes.
+func Capture() (l *logger.Logger, buf *bytes.Buffer, restore func()) {
+	l, buf = New()
+	return l, buf, logger.ReplaceGlobal(l)
+}
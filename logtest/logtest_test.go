@@ -0,0 +1,35 @@
+package logtest_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ZeRg0912/logger"
+	"github.com/ZeRg0912/logger/logtest"
+)
+
+func TestCapture(t *testing.T) {
+	_, buf, restore := logtest.Capture()
+
+	logger.Warn("disk almost full: %d%%", 93)
+	restore()
+	logger.Warn("after restore")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("captured %q, want the line logged before restore only", lines)
+	}
+	if !strings.Contains(lines[0], "WARN: logtest_test.go:") || !strings.HasSuffix(lines[0], " - disk almost full: 93%") {
+		t.Errorf("captured line = %q", lines[0])
+	}
+}
+
+func TestNew(t *testing.T) {
+	l, buf := logtest.New()
+
+	l.Debug("debug is captured too")
+	l.Trace("trace as well")
+	if !strings.Contains(buf.String(), "DEBUG: ") || !strings.Contains(buf.String(), "TRACE: ") {
+		t.Errorf("buffer = %q", buf)
+	}
+}
@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"net/http"
+	"time"
+)
+
+// Middleware logs every request handled by next through the global logger and
+// recovers panics (see (*Logger).Middleware). The global logger is resolved
+// on each request, so the middleware may be created before Init.
+func Middleware(next http.Handler) http.Handler {
+	return middleware(getDefault, next)
+}
+
+// Middleware logs method, path, status and duration of every request handled by next
+// at LevelInfo. A panic in next is logged at LevelError with the stack trace and
+// answered with 500 Internal Server Error (unless the response was already started);
+// http.ErrAbortHandler is re-panicked as net/http expects.
+func (l *Logger) Middleware(next http.Handler) http.Handler {
+	return middleware(func() *Logger { return l }, next)
+}
+
+func middleware(get func() *Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+
+		defer func() {
+			l := get()
+			if p := recover(); p != nil {
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+				if l != nil {
+					l.logPanic(p)
+				}
+				if !rec.wroteHeader {
+					http.Error(rec, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}
+
+			l.WithFields(Fields{
+				"method":   r.Method,
+				"path":     r.URL.Path,
+				"status":   rec.status(),
+				"duration": time.Since(start),
+			}).Info("http request")
+		}()
+
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// statusRecorder remembers the status code written through a ResponseWriter.
+type statusRecorder struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.code = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying ResponseWriter.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// status returns the written status code; 200 if the handler wrote nothing.
+func (r *statusRecorder) status() int {
+	if !r.wroteHeader {
+		return http.StatusOK
+	}
+	return r.code
+}
@@ -0,0 +1,133 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reconnect settings of NetworkWriter.
+const (
+	networkDialTimeout = 5 * time.Second
+	networkMinBackoff  = 100 * time.Millisecond
+	networkMaxBackoff  = 30 * time.Second
+)
+
+// NetworkWriter writes lines to a remote collector over TCP or UDP.
+// The connection is dialed on first write and re-dialed after a failure with
+// an exponential backoff (100ms up to 30s). While the collector is unreachable
+// lines go to the fallback writer instead of being lost.
+type NetworkWriter struct {
+	network  string
+	addr     string
+	fallback io.Writer
+
+	mu       sync.Mutex
+	conn     net.Conn
+	backoff  time.Duration
+	nextDial time.Time
+}
+
+// NewNetworkWriter creates a writer for url in the form "tcp://host:port" or "udp://host:port".
+// fallback (e.g. os.Stderr or a file) receives lines while the collector is unreachable;
+// nil drops them. The connection is established lazily.
+func NewNetworkWriter(url string, fallback io.Writer) (*NetworkWriter, error) {
+	network, addr, ok := strings.Cut(url, "://")
+	if !ok || addr == "" {
+		return nil, fmt.Errorf("logger: invalid network address %q, want tcp://host:port or udp://host:port", url)
+	}
+	if network != "tcp" && network != "udp" {
+		return nil, fmt.Errorf("logger: unsupported network %q, want tcp or udp", network)
+	}
+	return &NetworkWriter{network: network, addr: addr, fallback: fallback}, nil
+}
+
+// Write sends p to the collector, reconnecting once if the connection broke.
+// If the collector cannot be reached p is written to the fallback writer;
+// an error is returned only if the fallback fails too (or there is none).
+func (w *NetworkWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if w.conn == nil && !w.dialLocked() {
+			break
+		}
+		if _, err := w.conn.Write(p); err == nil {
+			return len(p), nil
+		}
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+
+	if w.fallback == nil {
+		return 0, fmt.Errorf("logger: %s://%s unreachable", w.network, w.addr)
+	}
+	return w.fallback.Write(p)
+}
+
+// dialLocked connects unless the backoff after a failed attempt has not expired yet.
+// Must be called under w.mu.
+func (w *NetworkWriter) dialLocked() bool {
+	now := time.Now()
+	if now.Before(w.nextDial) {
+		return false
+	}
+
+	conn, err := net.DialTimeout(w.network, w.addr, networkDialTimeout)
+	if err != nil {
+		switch {
+		case w.backoff == 0:
+			w.backoff = networkMinBackoff
+		case w.backoff < networkMaxBackoff:
+			w.backoff = min(w.backoff*2, networkMaxBackoff)
+		}
+		w.nextDial = now.Add(w.backoff)
+		return false
+	}
+
+	w.conn = conn
+	w.backoff = 0
+	w.nextDial = time.Time{}
+	return true
+}
+
+// Close closes the connection, if any.
+func (w *NetworkWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// AddNetworkSink sends lines of the global logger at level and above to a remote collector.
+// Returns ErrNotInitialized if the logger is not initialized.
+func AddNetworkSink(url string, level LogLevel) (*Sink, error) {
+	l := getDefault()
+	if l == nil {
+		return nil, ErrNotInitialized
+	}
+	return l.AddNetworkSink(url, level)
+}
+
+// AddNetworkSink attaches a NetworkWriter for url ("tcp://host:port" or "udp://host:port")
+// as an asynchronous sink receiving lines at level and above, so a slow collector
+// never blocks logging. While the collector is unreachable lines fall back to stderr.
+// The connection is closed by RemoveSink and Close.
+func (l *Logger) AddNetworkSink(url string, level LogLevel) (*Sink, error) {
+	_, stderr := l.consoleWriters()
+	w, err := NewNetworkWriter(url, stderr)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.addSink(w, level, defaultSinkQueueSize, OverflowDrop, w), nil
+}
@@ -0,0 +1,291 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Option configures a Logger created by NewWithOptions or InitWithOptions.
+// Options are applied in order before the log file is opened.
+type Option func(l *Logger) error
+
+// NewWithOptions creates an independent Logger configured by opts. Without options it
+// writes all levels to the console only. The caller is responsible for calling Close
+// on the returned logger when file output is used.
+//
+//	l, err := logger.NewWithOptions(
+//		logger.WithOutputMode(logger.Both),
+//		logger.WithConsoleLevel(logger.LevelInfo),
+//		logger.WithFile("logs/app.log"),
+//		logger.WithMaxSize(10<<20),
+//	)
+func NewWithOptions(opts ...Option) (*Logger, error) {
+	l := &Logger{core: &core{
+		outputMode:     ConsoleOnly,
+		fileMode:       defaultFileMode,
+		dirMode:        defaultDirMode,
+		sliceSeparator: defaultSliceFieldSeparator,
+		now:            time.Now,
+		stdout:         os.Stdout,
+		stderr:         os.Stderr,
+		stderrLevel:    LevelError,
+		caller:         callerConfig{include: true},
+	}}
+
+	for _, opt := range opts {
+		if err := opt(l); err != nil {
+			return nil, err
+		}
+	}
+	if err := l.validate(); err != nil {
+		return nil, err
+	}
+
+	// Create file writer if needed
+	if (l.outputMode == FileOnly || l.outputMode == Both) && l.fileWriter == nil {
+		// Same path as rotation, so every file follows one naming convention.
+		// l is not shared yet, so l.mu is not needed.
+		if err := l.openNewFileLocked(); err != nil {
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+// validate rejects configurations that would silently drop lines:
+// an undefined output mode, a file output mode without a file path or writer,
+// and undefined levels. l must not be shared yet.
+func (l *Logger) validate() error {
+	if !l.outputMode.valid() {
+		return fmt.Errorf("logger: invalid output mode %v", l.outputMode)
+	}
+	if (l.outputMode == FileOnly || l.outputMode == Both) && l.fileWriter == nil && l.basePath == "" {
+		return fmt.Errorf("logger: file path required for %v mode", l.outputMode)
+	}
+	if !l.consoleLevel.valid() {
+		return fmt.Errorf("logger: invalid console level %v", l.consoleLevel)
+	}
+	if !l.fileLevel.valid() {
+		return fmt.Errorf("logger: invalid file level %v", l.fileLevel)
+	}
+	return nil
+}
+
+// InitWithOptions initializes the global logger with a logger created by NewWithOptions.
+// Returns ErrAlreadyInitialized if the logger was already initialized.
+func InitWithOptions(opts ...Option) error {
+	return initDefault(func() (*Logger, error) {
+		return NewWithOptions(opts...)
+	})
+}
+
+// WithOutputMode sets where lines are written (ConsoleOnly by default).
+func WithOutputMode(mode OutputMode) Option {
+	return func(l *Logger) error {
+		l.outputMode = mode
+		return nil
+	}
+}
+
+// WithConsoleLevel sets the minimum level for console output (LevelDebug by default).
+func WithConsoleLevel(level LogLevel) Option {
+	return func(l *Logger) error {
+		l.consoleLevel = level
+		return nil
+	}
+}
+
+// WithFileLevel sets the minimum level for file output (LevelDebug by default).
+func WithFileLevel(level LogLevel) Option {
+	return func(l *Logger) error {
+		l.fileLevel = level
+		return nil
+	}
+}
+
+// WithFile sets the base path of log files, e.g. "logs/app.log" (see Init).
+func WithFile(path string) Option {
+	return func(l *Logger) error {
+		l.basePath = path
+		return nil
+	}
+}
+
+// WithWriter sends the file side of the output to w instead of a file (see NewWithWriter).
+func WithWriter(w io.Writer) Option {
+	return func(l *Logger) error {
+		if w == nil {
+			return errors.New("logger: writer is nil")
+		}
+		l.fileWriter = w
+		l.externalWriter = true
+		return nil
+	}
+}
+
+// WithMaxSize sets the file size in bytes that triggers rotation (0 disables it).
+func WithMaxSize(size int64) Option {
+	return func(l *Logger) error {
+		l.maxFileSize = size
+		return nil
+	}
+}
+
+// WithMaxLines sets the number of lines that triggers rotation (see SetMaxLines).
+func WithMaxLines(n int64) Option {
+	return func(l *Logger) error {
+		l.maxLines = n
+		return nil
+	}
+}
+
+// WithMaxBackups sets the number of rotated files to keep (see SetMaxBackups).
+func WithMaxBackups(n int) Option {
+	return func(l *Logger) error {
+		l.maxBackups = n
+		return nil
+	}
+}
+
+// WithMaxAge sets the age after which rotated files are deleted (see SetMaxAge).
+func WithMaxAge(age time.Duration) Option {
+	return func(l *Logger) error {
+		l.maxAge = age
+		return nil
+	}
+}
+
+// WithFormat sets the output format (see SetFormat).
+func WithFormat(format Format) Option {
+	return func(l *Logger) error {
+		l.format = format
+		return nil
+	}
+}
+
+// WithFormatter sets a custom formatter (see SetFormatter).
+func WithFormatter(f Formatter) Option {
+	return func(l *Logger) error {
+		l.formatter = f
+		return nil
+	}
+}
+
+// WithTimeFormat sets the timestamp layout of text lines (see SetTimeFormat).
+func WithTimeFormat(layout string) Option {
+	return func(l *Logger) error {
+		if err := validateTimeLayout(layout); err != nil {
+			return err
+		}
+		l.timeLayout = layout
+		return nil
+	}
+}
+
+// WithTimePrecision sets the fractional-second precision of timestamps (see SetTimePrecision).
+func WithTimePrecision(p TimePrecision) Option {
+	return func(l *Logger) error {
+		l.timePrecision = p
+		return nil
+	}
+}
+
+// WithConsoleHelpersRespectLevel applies the console level to ConsoleInfo and
+// ConsoleSuccess (see SetConsoleHelpersRespectLevel).
+func WithConsoleHelpersRespectLevel(enabled bool) Option {
+	return func(l *Logger) error {
+		l.helpersRespectLevel = enabled
+		return nil
+	}
+}
+
+// WithLevelWidth pads the level token of text lines (see SetLevelWidth).
+func WithLevelWidth(width int) Option {
+	return func(l *Logger) error {
+		l.levelWidth = width
+		return nil
+	}
+}
+
+// WithLineEnding sets the line terminator (see SetLineEnding).
+func WithLineEnding(ending string) Option {
+	return func(l *Logger) error {
+		if ending != "\n" {
+			l.lineEnding = ending
+		}
+		return nil
+	}
+}
+
+// WithLocation sets the time zone of timestamps and file names (see SetLocation).
+func WithLocation(loc *time.Location) Option {
+	return func(l *Logger) error {
+		l.location = loc
+		return nil
+	}
+}
+
+// WithFilePermissions sets permissions of created files and directories (see SetFilePermissions).
+func WithFilePermissions(fileMode, dirMode os.FileMode) Option {
+	return func(l *Logger) error {
+		if fileMode != 0 {
+			l.fileMode = fileMode
+		}
+		if dirMode != 0 {
+			l.dirMode = dirMode
+		}
+		return nil
+	}
+}
+
+// WithDefaultFields sets fields attached to every line (see SetDefaultFields).
+func WithDefaultFields(fields Fields) Option {
+	return func(l *Logger) error {
+		l.SetDefaultFields(fields)
+		return nil
+	}
+}
+
+// WithColorMode sets console coloring (see SetColorMode).
+func WithColorMode(mode ColorMode) Option {
+	return func(l *Logger) error {
+		l.colorMode = mode
+		return nil
+	}
+}
+
+// WithCallerPathMode sets how the caller file is rendered (see SetCallerPathMode).
+func WithCallerPathMode(mode CallerPathMode) Option {
+	return func(l *Logger) error {
+		l.caller.path = mode
+		return nil
+	}
+}
+
+// WithStderrLevel sets the lowest level written to stderr (see SetStderrLevel).
+func WithStderrLevel(level LogLevel) Option {
+	return func(l *Logger) error {
+		l.stderrLevel = level
+		return nil
+	}
+}
+
+// WithOmitSource removes the source segment from lines (see SetOmitSource).
+func WithOmitSource(enabled bool) Option {
+	return func(l *Logger) error {
+		l.caller.omitSource = enabled
+		return nil
+	}
+}
+
+// WithIncludeCaller enables or disables the caller lookup (see SetIncludeCaller).
+func WithIncludeCaller(enabled bool) Option {
+	return func(l *Logger) error {
+		l.caller.include = enabled
+		return nil
+	}
+}
@@ -0,0 +1,42 @@
+package logger
+
+import "os"
+
+// Default permissions of created log files and directories (before umask).
+const (
+	defaultFileMode os.FileMode = 0666
+	defaultDirMode  os.FileMode = 0755
+)
+
+// SetFilePermissions sets permissions of log files and directories created by the global logger.
+// Does nothing if the logger is not initialized.
+func SetFilePermissions(fileMode, dirMode os.FileMode) {
+	if l := getDefault(); l != nil {
+		l.SetFilePermissions(fileMode, dirMode)
+	}
+}
+
+// SetFilePermissions sets permissions of log files and directories created by the logger,
+// e.g. 0600 for logs that may contain sensitive data. Zero restores the default
+// (0666 for files, 0755 for directories); the process umask still applies.
+// The mode of the currently open file is changed as well; existing directories are left as is.
+func (l *Logger) SetFilePermissions(fileMode, dirMode os.FileMode) {
+	if fileMode == 0 {
+		fileMode = defaultFileMode
+	}
+	if dirMode == 0 {
+		dirMode = defaultDirMode
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.fileMode = fileMode
+	l.dirMode = dirMode
+
+	if file, ok := l.fileWriter.(*os.File); ok && file != nil && !l.externalWriter {
+		if err := file.Chmod(fileMode); err != nil {
+			l.lifecycleLocked("chmod_failed", Fields{"path": l.filePath, "error": err})
+		}
+	}
+}
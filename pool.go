@@ -0,0 +1,30 @@
+package logger
+
+import "sync"
+
+// maxPooledBufferSize caps buffers returned to linePool, so a single huge line
+// does not pin its memory for the lifetime of the process.
+const maxPooledBufferSize = 64 << 10
+
+// linePool holds scratch buffers used to render lines without intermediate strings.
+var linePool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 256)
+		return &b
+	},
+}
+
+// getLineBuffer returns an empty scratch buffer from linePool.
+func getLineBuffer() *[]byte {
+	b := linePool.Get().(*[]byte)
+	*b = (*b)[:0]
+	return b
+}
+
+// putLineBuffer returns b to linePool. b must not be used afterwards.
+func putLineBuffer(b *[]byte) {
+	if cap(*b) > maxPooledBufferSize {
+		return
+	}
+	linePool.Put(b)
+}
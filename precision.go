@@ -0,0 +1,66 @@
+package logger
+
+import "strings"
+
+// TimePrecision is the fractional-second precision of timestamps.
+type TimePrecision int
+
+const (
+	PrecisionSecond TimePrecision = iota // 15:04:05 (default)
+	PrecisionMilli                       // 15:04:05.000
+	PrecisionMicro                       // 15:04:05.000000
+	PrecisionNano                        // 15:04:05.000000000
+)
+
+// fraction returns the layout element for the fractional seconds of p.
+func (p TimePrecision) fraction() string {
+	switch p {
+	case PrecisionMilli:
+		return ".000"
+	case PrecisionMicro:
+		return ".000000"
+	case PrecisionNano:
+		return ".000000000"
+	}
+	return ""
+}
+
+// SetTimePrecision sets the timestamp precision of the global logger.
+// Does nothing if the logger is not initialized.
+func SetTimePrecision(p TimePrecision) {
+	if l := getDefault(); l != nil {
+		l.SetTimePrecision(p)
+	}
+}
+
+// SetTimePrecision adds fractional seconds to timestamps, e.g. to order events
+// when debugging latency: "2006/01/02 15:04:05.000000" in text mode and
+// "2006-01-02T15:04:05.000000Z07:00" in JSON and logfmt. Digits are never trimmed,
+// so lines stay aligned. It applies to custom layouts (SetTimeFormat) that contain
+// seconds but no fraction yet; GCP lines always carry nanoseconds.
+func (l *Logger) SetTimePrecision(p TimePrecision) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.timePrecision = p
+}
+
+// layoutWithPrecision inserts the fraction of p right after the seconds of layout.
+// Layouts without seconds, with a fraction already or the epoch special
+// layouts are returned unchanged.
+func layoutWithPrecision(layout string, p TimePrecision) string {
+	fraction := p.fraction()
+	if fraction == "" || layout == TimeFormatUnix || layout == TimeFormatUnixMilli {
+		return layout
+	}
+
+	i := strings.Index(layout, "05")
+	if i < 0 {
+		return layout
+	}
+	rest := layout[i+2:]
+	if strings.HasPrefix(rest, ".0") || strings.HasPrefix(rest, ".9") ||
+		strings.HasPrefix(rest, ",0") || strings.HasPrefix(rest, ",9") {
+		return layout
+	}
+	return layout[:i+2] + fraction + rest
+}
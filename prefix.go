@@ -0,0 +1,22 @@
+package logger
+
+// WithPrefix returns a child of the global logger that tags every line with "[prefix]".
+// Returns nil (a no-op logger) if the logger is not initialized.
+func WithPrefix(prefix string) *Logger {
+	return getDefault().WithPrefix(prefix)
+}
+
+// WithPrefix returns a child logger that tags every line with "[prefix]" right after
+// the level, e.g. "INFO [worker]: main.go:12 - started". Nested prefixes concatenate:
+// WithPrefix("api").WithPrefix("auth") renders "[api][auth]". In JSON formats and
+// for custom formatters the prefix is prepended to the message instead.
+// The child shares configuration and output with its parent, which is not modified.
+func (l *Logger) WithPrefix(prefix string) *Logger {
+	if l == nil {
+		return nil
+	}
+
+	child := l.clone()
+	child.prefix += "[" + prefix + "]"
+	return child
+}
@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"fmt"
+	"time"
+)
+
+// rateLimiter is a token bucket limiting messages of one level.
+// It is guarded by l.mu.
+type rateLimiter struct {
+	limit    int
+	interval time.Duration
+
+	tokens float64
+	last   time.Time
+
+	// suppressed counts messages dropped since the last summary.
+	suppressed uint64
+}
+
+// allow takes a token if one is available at now.
+func (r *rateLimiter) allow(now time.Time) bool {
+	if r.last.IsZero() {
+		r.tokens = float64(r.limit)
+	} else if elapsed := now.Sub(r.last); elapsed > 0 {
+		r.tokens += float64(r.limit) * float64(elapsed) / float64(r.interval)
+		if r.tokens > float64(r.limit) {
+			r.tokens = float64(r.limit)
+		}
+	}
+	r.last = now
+
+	if r.tokens < 1 {
+		r.suppressed++
+		return false
+	}
+	r.tokens--
+	return true
+}
+
+// reset refills the bucket so the next messages are logged in full.
+// The suppressed counter is kept for the next summary.
+func (r *rateLimiter) reset() {
+	r.tokens = float64(r.limit)
+	r.last = time.Time{}
+}
+
+// SetRateLimit limits the global logger to n messages of level per interval.
+// Does nothing if the logger is not initialized.
+func SetRateLimit(level LogLevel, n int, interval time.Duration) {
+	if l := getDefault(); l != nil {
+		l.SetRateLimit(level, n, interval)
+	}
+}
+
+// SetRateLimit limits messages of level to n per interval (a token bucket allowing
+// bursts of up to n). Messages over the limit are dropped and counted; the next line
+// written at that level is preceded by a "... N messages suppressed" summary.
+// n <= 0 or interval <= 0 removes the limit for level.
+func (l *Logger) SetRateLimit(level LogLevel, n int, interval time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if n <= 0 || interval <= 0 {
+		delete(l.rateLimits, level)
+		return
+	}
+	if l.rateLimits == nil {
+		l.rateLimits = make(map[LogLevel]*rateLimiter)
+	}
+	l.rateLimits[level] = &rateLimiter{limit: n, interval: interval}
+}
+
+// ResetSampling refills the rate limits of all levels of the global logger.
+// Does nothing if the logger is not initialized.
+func ResetSampling() {
+	if l := getDefault(); l != nil {
+		l.ResetSampling()
+	}
+}
+
+// ResetSampling refills the rate limits of all levels, so messages after
+// an important checkpoint are logged in full again.
+func (l *Logger) ResetSampling() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, r := range l.rateLimits {
+		r.reset()
+	}
+}
+
+// ResetSamplingLevel refills the rate limit of level of the global logger.
+// Does nothing if the logger is not initialized.
+func ResetSamplingLevel(level LogLevel) {
+	if l := getDefault(); l != nil {
+		l.ResetSamplingLevel(level)
+	}
+}
+
+// ResetSamplingLevel refills the rate limit of level only.
+func (l *Logger) ResetSamplingLevel(level LogLevel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r, ok := l.rateLimits[level]; ok {
+		r.reset()
+	}
+}
+
+// rateLimitLocked reports whether a message at level passes the rate limit.
+// If it does and earlier messages were suppressed, it also returns
+// the summary message to write first.
+// Must be called under l.mu.
+func (l *Logger) rateLimitLocked(level LogLevel) (allowed bool, summary string) {
+	r, ok := l.rateLimits[level]
+	if !ok {
+		return true, ""
+	}
+	if !r.allow(l.now()) {
+		return false, ""
+	}
+	if r.suppressed > 0 {
+		summary = fmt.Sprintf("... %d messages suppressed", r.suppressed)
+		r.suppressed = 0
+	}
+	return true, summary
+}
@@ -0,0 +1,83 @@
+package logger
+
+// ringBuffer keeps the last lines written by the logger. It is guarded by l.mu.
+type ringBuffer struct {
+	lines []string
+	next  int
+	full  bool
+}
+
+// add stores line, overwriting the oldest one when the buffer is full.
+func (r *ringBuffer) add(line string) {
+	r.lines[r.next] = line
+	r.next++
+	if r.next == len(r.lines) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// last returns up to n most recent lines, oldest first.
+func (r *ringBuffer) last(n int) []string {
+	size := r.next
+	if r.full {
+		size = len(r.lines)
+	}
+	if n <= 0 || n > size {
+		n = size
+	}
+
+	out := make([]string, n)
+	start := r.next - n
+	if start < 0 {
+		start += len(r.lines)
+	}
+	for i := range out {
+		out[i] = r.lines[(start+i)%len(r.lines)]
+	}
+	return out
+}
+
+// SetRecentBuffer keeps the last size lines of the global logger in memory.
+// Does nothing if the logger is not initialized.
+func SetRecentBuffer(size int) {
+	if l := getDefault(); l != nil {
+		l.SetRecentBuffer(size)
+	}
+}
+
+// SetRecentBuffer keeps the last size written lines in memory for Recent, e.g. for
+// a debug endpoint. Lines are stored as rendered, with the trailing newline.
+// size <= 0 disables the buffer. Changing the size drops the stored lines.
+func (l *Logger) SetRecentBuffer(size int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if size <= 0 {
+		l.recent = nil
+		return
+	}
+	l.recent = &ringBuffer{lines: make([]string, size)}
+}
+
+// Recent returns up to n most recent lines of the global logger, newest last.
+// Returns nil if the logger is not initialized.
+func Recent(n int) []string {
+	l := getDefault()
+	if l == nil {
+		return nil
+	}
+	return l.Recent(n)
+}
+
+// Recent returns up to n most recent lines kept by SetRecentBuffer, newest last.
+// n <= 0 returns all stored lines. Returns nil if the buffer is disabled.
+func (l *Logger) Recent(n int) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.recent == nil {
+		return nil
+	}
+	return l.recent.last(n)
+}
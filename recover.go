@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// RecoverAndLog recovers a panic, logs it with a stack trace at error level
+// using the global logger, flushes the log file and re-panics with the same value.
+// It must be deferred directly: defer logger.RecoverAndLog()
+func RecoverAndLog() {
+	if r := recover(); r != nil {
+		if l := getDefault(); l != nil {
+			l.logPanic(r)
+		}
+		panic(r)
+	}
+}
+
+// RecoverAndLog recovers a panic, logs it with a stack trace at error level,
+// flushes the log file and re-panics with the same value.
+// It must be deferred directly: defer l.RecoverAndLog()
+func (l *Logger) RecoverAndLog() {
+	if r := recover(); r != nil {
+		l.logPanic(r)
+		panic(r)
+	}
+}
+
+// logPanic writes the recovered panic value with the current stack and flushes the logger.
+// It must be called directly by the deferred hook; the source of the line is
+// the function that panicked, not the hook.
+func (l *Logger) logPanic(r interface{}) {
+	l.WithCallerSkip(panicCallerSkip()).log(LevelError, "panic: %v\n%s", r, debug.Stack())
+	_ = l.Flush()
+}
+
+// panicCallerSkip returns the caller skip that makes a log call in logPanic report
+// the frame that panicked: the first frame above the deferred hook that is not part
+// of the runtime panic machinery (runtime.gopanic, runtime.sigpanic, ...).
+func panicCallerSkip() int {
+	var pcs [32]uintptr
+	// Skip runtime.Callers, panicCallerSkip, logPanic and the deferred hook
+	n := runtime.Callers(4, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+
+	// Skip 0 would report the hook itself, so the first frame after it is 1
+	skip := 1
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") || !more {
+			return skip
+		}
+		skip++
+	}
+}
@@ -0,0 +1,89 @@
+package logger
+
+import "regexp"
+
+// redactionMask replaces redacted field values and message fragments.
+const redactionMask = "***"
+
+// SetRedactFields masks the values of fields with the given keys in all lines
+// of the global logger. Does nothing if the logger is not initialized.
+func SetRedactFields(keys ...string) {
+	if l := getDefault(); l != nil {
+		l.SetRedactFields(keys...)
+	}
+}
+
+// SetRedactFields masks the values of fields with the given keys (e.g. "password",
+// "token") with "***" before lines reach any destination. Keys are matched exactly.
+// Calling it without keys disables field redaction.
+func (l *Logger) SetRedactFields(keys ...string) {
+	var set map[string]bool
+	if len(keys) > 0 {
+		set = make(map[string]bool, len(keys))
+		for _, k := range keys {
+			set[k] = true
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.redactKeys = set
+}
+
+// SetRedactPatterns masks message fragments matching any of patterns in all lines
+// of the global logger. Returns ErrNotInitialized if the logger is not initialized.
+func SetRedactPatterns(patterns ...string) error {
+	l := getDefault()
+	if l == nil {
+		return ErrNotInitialized
+	}
+	return l.SetRedactPatterns(patterns...)
+}
+
+// SetRedactPatterns masks message fragments matching any of the regular expressions
+// with "***", e.g. `Bearer \S+` or `password=\S+`. Patterns are compiled first; on error
+// the current patterns are kept. Calling it without patterns disables message redaction.
+func (l *Logger) SetRedactPatterns(patterns ...string) error {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return err
+		}
+		compiled = append(compiled, re)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.redactPatterns = compiled
+	return nil
+}
+
+// redactLocked applies field and message redaction. fields is never modified.
+// Must be called under l.mu.
+func (l *Logger) redactLocked(msg string, fields Fields) (string, Fields) {
+	for _, re := range l.redactPatterns {
+		msg = re.ReplaceAllString(msg, redactionMask)
+	}
+
+	if len(l.redactKeys) == 0 || len(fields) == 0 {
+		return msg, fields
+	}
+	var masked Fields
+	for k := range fields {
+		if !l.redactKeys[k] {
+			continue
+		}
+		if masked == nil {
+			masked = make(Fields, len(fields))
+			for k, v := range fields {
+				masked[k] = v
+			}
+		}
+		masked[k] = redactionMask
+	}
+	if masked == nil {
+		return msg, fields
+	}
+	return msg, masked
+}
@@ -0,0 +1,85 @@
+package logger
+
+import "sync"
+
+// LoggerNameField is the field carrying the name of loggers returned by GetLogger.
+const LoggerNameField = "logger"
+
+// namedLogger is a registry entry; owned loggers were created from a Config
+// and are closed by the registry.
+type namedLogger struct {
+	l     *Logger
+	owned bool
+}
+
+var (
+	registry   = make(map[string]namedLogger)
+	registryMu sync.Mutex
+)
+
+// GetLogger returns the logger registered under name, creating it on first use,
+// so any part of an application can fetch it without passing it around.
+// Repeated calls with the same name return the same instance.
+//
+// A logger configured with SetLoggerConfig has its own output. Otherwise the logger
+// is a child of the global logger as it was at creation time (or a console-only
+// logger if the global one is not initialized); Reset and MustReinit drop it, so
+// the next call returns a child of the new global logger. Every line carries the
+// name as the "logger" field.
+func GetLogger(name string) *Logger {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if e, ok := registry[name]; ok {
+		return e.l
+	}
+
+	base := getDefault()
+	if base == nil {
+		// Console-only without options cannot fail
+		base, _ = NewWithOptions()
+	}
+	l := base.WithFields(Fields{LoggerNameField: name})
+	registry[name] = namedLogger{l: l}
+	return l
+}
+
+// SetLoggerConfig creates the logger registered under name from c and replaces
+// the previous one; a previous logger created from a Config is closed.
+// Returns the validation or creation error and keeps the previous logger on error.
+func SetLoggerConfig(name string, c Config) error {
+	l, err := NewFromConfig(c)
+	if err != nil {
+		return err
+	}
+	l = l.WithFields(Fields{LoggerNameField: name})
+
+	registryMu.Lock()
+	prev, ok := registry[name]
+	registry[name] = namedLogger{l: l, owned: true}
+	registryMu.Unlock()
+
+	if ok && prev.owned {
+		return prev.l.Close()
+	}
+	return nil
+}
+
+// closeRegistry closes the loggers created from a Config and empties the registry.
+func closeRegistry() error {
+	registryMu.Lock()
+	entries := registry
+	registry = make(map[string]namedLogger)
+	registryMu.Unlock()
+
+	var firstErr error
+	for _, e := range entries {
+		if !e.owned {
+			continue
+		}
+		if err := e.l.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,49 @@
+package logger
+
+import "os"
+
+// SetSizeResync makes the global logger re-read the log file size every n writes.
+// Does nothing if the logger is not initialized.
+func SetSizeResync(n int) {
+	if l := getDefault(); l != nil {
+		l.SetSizeResync(n)
+	}
+}
+
+// SetSizeResync makes the logger stat the log file every n writes and replace
+// the tracked size with the real one, so size rotation stays correct when another
+// process truncates or appends to the same file. The size is always re-read when
+// a file is opened by Rotate or Reopen. n <= 0 disables the periodic resync.
+func (l *Logger) SetSizeResync(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.resyncEvery = n
+	l.writesSinceResync = 0
+}
+
+// resyncSizeLocked re-reads the file size if the resync interval has elapsed.
+// Lines pending in the batch are counted on top of the size on disk.
+// Must be called under l.mu.
+func (l *Logger) resyncSizeLocked() {
+	if l.resyncEvery <= 0 || l.externalWriter {
+		return
+	}
+	l.writesSinceResync++
+	if l.writesSinceResync < l.resyncEvery {
+		return
+	}
+	l.writesSinceResync = 0
+
+	file, ok := l.fileWriter.(*os.File)
+	if !ok || file == nil {
+		return
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		return
+	}
+	l.currentSize = stat.Size()
+	if l.batch != nil {
+		l.currentSize += int64(l.batch.w.Buffered())
+	}
+}
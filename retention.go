@@ -0,0 +1,142 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SetMaxBackups limits the number of rotated (inactive) log files kept on disk
+// by the global logger. After each rotation the oldest files beyond n are deleted.
+// 0 keeps all files. Does nothing if the logger is not initialized.
+func SetMaxBackups(n int) {
+	if l := getDefault(); l != nil {
+		l.SetMaxBackups(n)
+	}
+}
+
+// SetMaxBackups limits the number of rotated (inactive) log files kept on disk.
+// After each rotation the oldest files beyond n are deleted. 0 keeps all files.
+func (l *Logger) SetMaxBackups(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxBackups = n
+}
+
+// SetMaxAge makes the global logger delete rotated log files whose name timestamp
+// is older than d. Files are checked after each rotation. 0 disables age-based cleanup.
+// Does nothing if the logger is not initialized.
+func SetMaxAge(d time.Duration) {
+	if l := getDefault(); l != nil {
+		l.SetMaxAge(d)
+	}
+}
+
+// SetMaxAge makes this logger delete rotated log files whose name timestamp
+// is older than d. Files are checked after each rotation. 0 disables age-based cleanup.
+// Files whose names do not contain a valid timestamp are left alone.
+func (l *Logger) SetMaxAge(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxAge = d
+}
+
+// logFile is a log file on disk that belongs to basePath.
+type logFile struct {
+	path string
+	time time.Time
+}
+
+// listLogFiles returns files created from basePath (except the active one),
+// sorted from oldest to newest by the timestamp in their names (interpreted in loc).
+// Files whose names do not contain a valid timestamp are skipped.
+func listLogFiles(basePath, activePath string, loc *time.Location) ([]logFile, error) {
+	matches, err := filepath.Glob(pathWithSuffix(basePath, "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]logFile, 0, len(matches))
+	for _, path := range matches {
+		if path == activePath {
+			continue
+		}
+		t, ok := parseLogPathTime(basePath, path, loc)
+		if !ok {
+			continue
+		}
+		files = append(files, logFile{path: path, time: t})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].time.Equal(files[j].time) {
+			// Same timestamp: collision suffixes _01, _02, ... sort by name
+			return files[i].path < files[j].path
+		}
+		return files[i].time.Before(files[j].time)
+	})
+	return files, nil
+}
+
+// parseLogPathTime extracts the timestamp from a path produced by pathWithSuffix
+// with a timestampSuffix (optionally followed by a _NN collision counter).
+func parseLogPathTime(basePath, path string, loc *time.Location) (time.Time, bool) {
+	base := filepath.Base(basePath)
+	ext := filepath.Ext(base)
+	prefix := base[:len(base)-len(ext)] + "_"
+
+	name := filepath.Base(path)
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+		return time.Time{}, false
+	}
+	suffix := name[len(prefix) : len(name)-len(ext)]
+
+	// Drop collision counter: 02.01.2006_15-04-05.000_01
+	if len(suffix) > len(timestampLayout) {
+		suffix = suffix[:len(timestampLayout)]
+	}
+
+	t, err := time.ParseInLocation(timestampLayout, suffix, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// pruneBackupsLocked deletes rotated files older than maxAge and the oldest
+// rotated files beyond maxBackups.
+// Listing and deletion errors are reported via OnError and LastError and do not stop logging.
+// Must be called under l.mu.
+func (l *Logger) pruneBackupsLocked() {
+	if (l.maxBackups <= 0 && l.maxAge <= 0) || l.basePath == "" {
+		return
+	}
+
+	files, err := listLogFiles(l.basePath, l.filePath, l.nowLocked().Location())
+	if err != nil {
+		l.reportErrorLocked("list old log files", err)
+		return
+	}
+
+	remove := 0
+	if l.maxBackups > 0 && len(files) > l.maxBackups {
+		remove = len(files) - l.maxBackups
+	}
+	if l.maxAge > 0 {
+		cutoff := l.now().Add(-l.maxAge)
+		for remove < len(files) && files[remove].time.Before(cutoff) {
+			remove++
+		}
+	}
+
+	for _, f := range files[:remove] {
+		if err := os.Remove(f.path); err != nil {
+			l.reportErrorLocked("remove old log file", err)
+			l.lifecycleLocked("prune_failed", Fields{"path": f.path, "error": err})
+			continue
+		}
+		l.lifecycleLocked("prune", Fields{"path": f.path})
+	}
+}
@@ -0,0 +1,54 @@
+package logger
+
+// rotation is a file switch waiting to be reported to the OnRotate callback.
+type rotation struct {
+	oldPath string
+	newPath string
+}
+
+// OnRotate sets a callback of the global logger invoked after the log file is rotated.
+// Does nothing if the logger is not initialized.
+func OnRotate(fn func(oldPath, newPath string)) {
+	if l := getDefault(); l != nil {
+		l.OnRotate(fn)
+	}
+}
+
+// OnRotate sets a callback invoked after the log file is rotated (by size, line count
+// or Rotate) with the paths of the closed and the new file, e.g. to start tailing the new
+// one. It runs outside the logger lock, so it may log, but it delays the log call that
+// triggered the rotation and must not block. nil removes it.
+func (l *Logger) OnRotate(fn func(oldPath, newPath string)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onRotate = fn
+	l.rotations = nil
+}
+
+// recordRotationLocked queues a rotation for notifyRotations.
+// Must be called under l.mu.
+func (l *Logger) recordRotationLocked(oldPath, newPath string) {
+	if l.onRotate != nil {
+		l.rotations = append(l.rotations, rotation{oldPath: oldPath, newPath: newPath})
+		l.rotationsPending.Store(true)
+	}
+}
+
+// notifyRotations passes queued rotations to the OnRotate callback.
+// Must not be called under l.mu.
+func (l *Logger) notifyRotations() {
+	// Checked without the lock, so log calls do not pay for the callback support
+	if !l.rotationsPending.Load() {
+		return
+	}
+
+	l.mu.Lock()
+	fn, pending := l.onRotate, l.rotations
+	l.rotations = nil
+	l.rotationsPending.Store(false)
+	l.mu.Unlock()
+
+	for _, r := range pending {
+		fn(r.oldPath, r.newPath)
+	}
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// closeSnapshot holds what CloseWithTimeout needs when Close does not finish in time.
+type closeSnapshot struct {
+	file  *os.File
+	async *asyncQueue
+	sinks []*Sink
+}
+
+// CloseWithTimeout closes the global logger and the named loggers (see GetLogger),
+// giving up on the global logger after d.
+func CloseWithTimeout(d time.Duration) error {
+	regErr := closeRegistry()
+
+	l := getDefault()
+	if l == nil {
+		return regErr
+	}
+	if err := l.CloseWithTimeout(d); err != nil {
+		return err
+	}
+	return regErr
+}
+
+// CloseWithTimeout works like Close but does not block for longer than d, e.g. when a sink
+// or the file system is wedged during container shutdown. If draining does not finish in
+// time, the log file is closed forcibly and an error reports how many queued entries
+// (async queue and sinks) were not written. The stuck Close keeps running in the background.
+func (l *Logger) CloseWithTimeout(d time.Duration) error {
+	snap := make(chan closeSnapshot, 1)
+	done := make(chan error, 1)
+	go func() {
+		snap <- l.closeSnapshot()
+		done <- l.Close()
+	}()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+	}
+
+	var s closeSnapshot
+	select {
+	case s = <-snap:
+	default:
+		return fmt.Errorf("logger: close timed out after %v: logger is blocked", d)
+	}
+
+	unflushed := 0
+	if s.async != nil {
+		unflushed += len(s.async.ch)
+	}
+	for _, sink := range s.sinks {
+		unflushed += sink.Pending()
+	}
+	if s.file != nil {
+		_ = s.file.Close()
+	}
+	return fmt.Errorf("logger: close timed out after %v with %d entries unflushed", d, unflushed)
+}
+
+// closeSnapshot captures the open file and the queues before Close starts draining them.
+func (l *Logger) closeSnapshot() closeSnapshot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s := closeSnapshot{
+		async: l.async,
+		sinks: append([]*Sink(nil), l.sinks...),
+	}
+	if file, ok := l.fileWriter.(*os.File); ok && !l.externalWriter && file != l.borrowedFile {
+		s.file = file
+	}
+	return s
+}
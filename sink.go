@@ -0,0 +1,190 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy defines what happens when a bounded queue is full.
+type OverflowPolicy int
+
+const (
+	OverflowDrop  OverflowPolicy = iota // Drop the new line and count it
+	OverflowBlock                       // Wait until the queue has room
+)
+
+// String returns the policy name, e.g. "Drop", or "OverflowPolicy(n)" for an unknown value.
+func (policy OverflowPolicy) String() string {
+	switch policy {
+	case OverflowDrop:
+		return "Drop"
+	case OverflowBlock:
+		return "Block"
+	}
+	return fmt.Sprintf("OverflowPolicy(%d)", int(policy))
+}
+
+// defaultSinkQueueSize is used when AddSink is called with a non-positive queue size.
+const defaultSinkQueueSize = 1024
+
+// Sink is an additional log destination (e.g. a network connection) that receives
+// lines through its own bounded queue drained by a dedicated goroutine, so a slow
+// or stalled sink does not block console and file output.
+type Sink struct {
+	w       io.Writer
+	level   LogLevel
+	policy  OverflowPolicy
+	queue   chan []byte
+	done    chan struct{}
+	dropped atomic.Uint64
+	errors  atomic.Uint64
+	once    sync.Once
+
+	// closer, if set, is closed after the sink is stopped (the sink owns w).
+	closer io.Closer
+}
+
+// AddSink attaches w to the global logger as an asynchronous sink.
+// Returns nil if the logger is not initialized.
+func AddSink(w io.Writer, level LogLevel, queueSize int, policy OverflowPolicy) *Sink {
+	l := getDefault()
+	if l == nil {
+		return nil
+	}
+	return l.AddSink(w, level, queueSize, policy)
+}
+
+// AddSink attaches w as an asynchronous sink that receives every line with
+// level >= level, independently of the output mode. Lines are queued (up to
+// queueSize) and written by a dedicated goroutine. When the queue is full the
+// policy decides whether the line is dropped (counted in Dropped) or the caller
+// waits; OverflowBlock stalls all output of this logger while the sink is full.
+// The sink is drained and stopped by Close or RemoveSink.
+func (l *Logger) AddSink(w io.Writer, level LogLevel, queueSize int, policy OverflowPolicy) *Sink {
+	return l.addSink(w, level, queueSize, policy, nil)
+}
+
+// addSink attaches a sink; closer, if not nil, is closed when the sink is stopped.
+func (l *Logger) addSink(w io.Writer, level LogLevel, queueSize int, policy OverflowPolicy, closer io.Closer) *Sink {
+	if queueSize <= 0 {
+		queueSize = defaultSinkQueueSize
+	}
+
+	s := &Sink{
+		w:      w,
+		level:  level,
+		policy: policy,
+		queue:  make(chan []byte, queueSize),
+		done:   make(chan struct{}),
+		closer: closer,
+	}
+	go s.run()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sinks = append(l.sinks, s)
+	return s
+}
+
+// RemoveSink detaches s from this logger, waits until its queue is drained and stops it.
+func (l *Logger) RemoveSink(s *Sink) {
+	l.mu.Lock()
+	for i, cur := range l.sinks {
+		if cur == s {
+			l.sinks = append(l.sinks[:i:i], l.sinks[i+1:]...)
+			break
+		}
+	}
+	l.mu.Unlock()
+
+	s.stop()
+}
+
+// Dropped returns the number of lines dropped because the sink queue was full.
+func (s *Sink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Errors returns the number of lines the sink writer failed to write.
+func (s *Sink) Errors() uint64 {
+	return s.errors.Load()
+}
+
+// Pending returns the number of lines waiting in the sink queue.
+func (s *Sink) Pending() int {
+	return len(s.queue)
+}
+
+// enqueue hands a line to the sink goroutine according to the overflow policy.
+// Must be called under the owning logger's mu, which guarantees the queue is open.
+func (s *Sink) enqueue(line string) {
+	data := []byte(line)
+	if s.policy == OverflowBlock {
+		s.queue <- data
+		return
+	}
+
+	select {
+	case s.queue <- data:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// run writes queued lines until the queue is closed.
+func (s *Sink) run() {
+	defer close(s.done)
+	for data := range s.queue {
+		if _, err := s.w.Write(data); err != nil {
+			s.errors.Add(1)
+		}
+	}
+}
+
+// stop closes the queue and waits until the remaining lines are written.
+func (s *Sink) stop() {
+	s.once.Do(func() {
+		close(s.queue)
+		<-s.done
+		if s.closer != nil {
+			_ = s.closer.Close()
+		}
+	})
+	<-s.done
+}
+
+// writeSinks queues line to every sink accepting level and sends it to syslog.
+// Must be called under l.mu.
+func (l *Logger) writeSinks(level LogLevel, line string) {
+	for _, s := range l.sinks {
+		if level >= s.level {
+			s.enqueue(line)
+		}
+	}
+	l.writeSyslogLocked(level, line)
+}
+
+// sinksEnabled reports whether any sink or syslog accepts level.
+// Must be called under l.mu.
+func (l *Logger) sinksEnabled(level LogLevel) bool {
+	if l.syslog != nil && level >= l.syslog.level {
+		return true
+	}
+	for _, s := range l.sinks {
+		if level >= s.level {
+			return true
+		}
+	}
+	return false
+}
+
+// closeSinksLocked drains and stops all sinks.
+// Must be called under l.mu.
+func (l *Logger) closeSinksLocked() {
+	for _, s := range l.sinks {
+		s.stop()
+	}
+	l.sinks = nil
+}
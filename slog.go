@@ -0,0 +1,133 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+)
+
+// Handler is a slog.Handler that writes records through a Logger,
+// respecting its output mode, levels, format and file rotation.
+// Attributes are rendered as fields; groups prefix attribute keys ("group.key").
+type Handler struct {
+	l      *Logger
+	fields Fields
+	group  string
+}
+
+// NewSlogHandler returns a slog.Handler writing to l.
+// If l is nil, the global logger is used (looked up on every record).
+//
+//	slog.SetDefault(slog.New(logger.NewSlogHandler(nil)))
+func NewSlogHandler(l *Logger) *Handler {
+	return &Handler{l: l}
+}
+
+// logger returns the target Logger or nil if the global logger is not initialized.
+func (h *Handler) logger() *Logger {
+	if h.l != nil {
+		return h.l
+	}
+	return getDefault()
+}
+
+// slogLevel maps a slog.Level onto a LogLevel.
+func slogLevel(level slog.Level) LogLevel {
+	switch {
+	case level < slog.LevelDebug:
+		return LevelTrace
+	case level < slog.LevelInfo:
+		return LevelDebug
+	case level < slog.LevelWarn:
+		return LevelInfo
+	case level < slog.LevelError:
+		return LevelWarn
+	default:
+		return LevelError
+	}
+}
+
+// Enabled reports whether the logger would write a record at level.
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	l := h.logger()
+	return l != nil && l.IsEnabled(slogLevel(level))
+}
+
+// Handle writes the record with the context fields (see ContextWithFields), the handler
+// and record attributes as fields.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	l := h.logger()
+	if l == nil {
+		return nil
+	}
+
+	ctxFields := FieldsFromContext(ctx)
+	fields := make(Fields, len(ctxFields)+len(h.fields)+r.NumAttrs())
+	for k, v := range ctxFields {
+		fields[k] = v
+	}
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addSlogAttr(fields, h.group, a)
+		return true
+	})
+
+	caller := l.callerSettings()
+	sourceInfo := unknownSource
+	var file string
+	if (caller.render() || len(caller.sourceLevels) > 0) && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		file = frame.File
+		if caller.render() {
+			sourceInfo = caller.format(r.PC, frame.File, frame.Line)
+		}
+	}
+
+	level := slogLevel(r.Level)
+	if l = l.forSource(caller, level, file); l == nil {
+		return nil
+	}
+	l.WithFields(fields).output(level, sourceInfo, r.Message)
+	return nil
+}
+
+// WithAttrs returns a handler that adds attrs to every record.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(Fields, len(h.fields)+len(attrs))
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	for _, a := range attrs {
+		addSlogAttr(fields, h.group, a)
+	}
+	return &Handler{l: h.l, fields: fields, group: h.group}
+}
+
+// WithGroup returns a handler that prefixes keys of subsequent attributes with name.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &Handler{l: h.l, fields: h.fields, group: h.group + name + "."}
+}
+
+// addSlogAttr stores a into fields under prefix, flattening nested groups.
+func addSlogAttr(fields Fields, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix = prefix + a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			addSlogAttr(fields, groupPrefix, ga)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	fields[prefix+a.Key] = v.Any()
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// StackField is the field holding the stack trace of error lines in JSON formats
+// (see SetStackOnError).
+const StackField = "stack"
+
+// SetStackOnError enables or disables stack traces on error lines of the global logger.
+// Does nothing if the logger is not initialized.
+func SetStackOnError(enabled bool) {
+	if l := getDefault(); l != nil {
+		l.SetStackOnError(enabled)
+	}
+}
+
+// SetStackOnError enables or disables capturing the goroutine stack for lines
+// at LevelError and above. In text format the stack is written under the message,
+// otherwise it is passed as the "stack" field. Frames of the logger itself
+// (and those skipped by WithCallerSkip) are trimmed. Disabled by default.
+func (l *Logger) SetStackOnError(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.caller.stack = enabled
+}
+
+// captureStack renders the stack of the calling goroutine, starting skip frames
+// above captureStack's caller, in the style of runtime/debug.Stack:
+//
+//	main.handle(...)
+//		/app/main.go:42
+func captureStack(skip int) string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var b strings.Builder
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "runtime.goexit" {
+			fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		}
+		if !more {
+			break
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// withStack returns a child logger carrying stack for a single line.
+func (l *Logger) withStack(stack string) *Logger {
+	child := l.clone()
+	child.stack = stack
+	return child
+}
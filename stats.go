@@ -0,0 +1,44 @@
+package logger
+
+import "sync/atomic"
+
+// levelCounters counts written messages per level, indexed by level - LevelTrace.
+// The counters are atomic, so Stats does not contend with writers for l.mu.
+type levelCounters [LevelError - LevelTrace + 1]atomic.Uint64
+
+// add records a message written at level.
+func (c *levelCounters) add(level LogLevel) {
+	if level.valid() {
+		c[level-LevelTrace].Add(1)
+	}
+}
+
+// load returns the number of messages written at level.
+func (c *levelCounters) load(level LogLevel) uint64 {
+	if !level.valid() {
+		return 0
+	}
+	return c[level-LevelTrace].Load()
+}
+
+// Stats returns the number of messages the global logger wrote per level.
+// Returns nil if the logger is not initialized.
+func Stats() map[LogLevel]uint64 {
+	l := getDefault()
+	if l == nil {
+		return nil
+	}
+	return l.Stats()
+}
+
+// Stats returns the number of messages written per level since the logger was created,
+// e.g. for a /metrics endpoint or alerting on error-rate spikes. Messages filtered by
+// level, collapsed by SetDeduplicate, suppressed by SetRateLimit or discarded in
+// dry-run mode are not counted. The returned map is a copy with an entry for every level.
+func (l *Logger) Stats() map[LogLevel]uint64 {
+	stats := make(map[LogLevel]uint64, len(l.levelCounts))
+	for level := LevelTrace; level <= LevelError; level++ {
+		stats[level] = l.levelCounts.load(level)
+	}
+	return stats
+}
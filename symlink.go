@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SetSymlinkCurrent enables or disables the "current" symlink of the global logger.
+// Does nothing if the logger is not initialized.
+func SetSymlinkCurrent(enabled bool) {
+	if l := getDefault(); l != nil {
+		l.SetSymlinkCurrent(enabled)
+	}
+}
+
+// SetSymlinkCurrent makes the base path (e.g. logs/app.log) a symlink to the active
+// timestamped file, updated after every rotation, so `tail -F logs/app.log` always
+// follows the newest log. An existing regular file at the base path is never replaced.
+// Where symlinks are not available (e.g. Windows without the required privilege)
+// logging continues without the link and a "symlink_failed" lifecycle event is emitted.
+func (l *Logger) SetSymlinkCurrent(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.symlinkCurrent = enabled
+	if enabled && l.filePath != "" {
+		l.updateSymlinkLocked()
+	}
+}
+
+// updateSymlinkLocked atomically points the base path symlink at l.filePath:
+// a temporary link is created next to it and renamed over the old one.
+// Must be called under l.mu.
+func (l *Logger) updateSymlinkLocked() {
+	if err := replaceSymlink(l.basePath, l.filePath); err != nil {
+		l.lifecycleLocked("symlink_failed", Fields{"path": l.basePath, "error": err})
+	}
+}
+
+// replaceSymlink atomically makes link a symlink to target (relative to the link's directory).
+func replaceSymlink(link, target string) error {
+	if info, err := os.Lstat(link); err == nil && info.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("%s exists and is not a symlink", link)
+	}
+
+	tmp := link + ".tmp"
+	_ = os.Remove(tmp)
+	if err := os.Symlink(filepath.Base(target), tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, link); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package logger
+
+import "strings"
+
+// syslogWriter is the subset of *log/syslog.Writer used by the syslog destination.
+type syslogWriter interface {
+	Debug(m string) error
+	Info(m string) error
+	Warning(m string) error
+	Err(m string) error
+	Close() error
+}
+
+// syslogOutput is the syslog destination set by SetSyslog.
+type syslogOutput struct {
+	w     syslogWriter
+	level LogLevel
+}
+
+// send writes line with the syslog severity matching level.
+func (s *syslogOutput) send(level LogLevel, line string) error {
+	line = strings.TrimRight(line, "\r\n")
+	switch {
+	case level >= LevelError:
+		return s.w.Err(line)
+	case level == LevelWarn:
+		return s.w.Warning(line)
+	case level == LevelInfo:
+		return s.w.Info(line)
+	}
+	return s.w.Debug(line)
+}
+
+// DisableSyslog closes the syslog destination of the global logger.
+// Does nothing if the logger is not initialized.
+func DisableSyslog() error {
+	l := getDefault()
+	if l == nil {
+		return nil
+	}
+	return l.DisableSyslog()
+}
+
+// DisableSyslog closes the syslog destination set by SetSyslog, if any.
+func (l *Logger) DisableSyslog() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closeSyslogLocked()
+}
+
+// setSyslogLocked replaces the syslog destination, closing the previous one.
+// Must be called under l.mu.
+func (l *Logger) setSyslogLocked(s *syslogOutput) error {
+	err := l.closeSyslogLocked()
+	l.syslog = s
+	return err
+}
+
+// closeSyslogLocked closes the syslog destination, if any.
+// Must be called under l.mu.
+func (l *Logger) closeSyslogLocked() error {
+	if l.syslog == nil {
+		return nil
+	}
+	err := l.syslog.w.Close()
+	l.syslog = nil
+	return err
+}
+
+// writeSyslogLocked sends line to syslog if it accepts level.
+// Must be called under l.mu.
+func (l *Logger) writeSyslogLocked(level LogLevel, line string) {
+	if l.syslog == nil || level < l.syslog.level {
+		return
+	}
+	if err := l.syslog.send(level, line); err != nil {
+		l.reportErrorLocked("write syslog", err)
+	}
+}
@@ -0,0 +1,32 @@
+//go:build !windows && !plan9
+
+package logger
+
+import "log/syslog"
+
+// SetSyslog sends lines of the global logger at level and above to syslog.
+// Returns ErrNotInitialized if the logger is not initialized.
+func SetSyslog(level LogLevel, network, raddr string, facility syslog.Priority, tag string) error {
+	l := getDefault()
+	if l == nil {
+		return ErrNotInitialized
+	}
+	return l.SetSyslog(level, network, raddr, facility, tag)
+}
+
+// SetSyslog sends lines at level and above to syslog in addition to console and file.
+// network and raddr select the daemon ("" and "" for the local one, or e.g. "udp" and
+// "logs.example.com:514"); facility is e.g. syslog.LOG_DAEMON and tag the program name.
+// Levels map to severities: Trace and Debug to LOG_DEBUG, Info to LOG_INFO, Warn to LOG_WARNING,
+// Error to LOG_ERR. A previous syslog destination is closed.
+// Not available on Windows and Plan 9.
+func (l *Logger) SetSyslog(level LogLevel, network, raddr string, facility syslog.Priority, tag string) error {
+	w, err := syslog.Dial(network, raddr, facility|syslog.LOG_INFO, tag)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.setSyslogLocked(&syslogOutput{w: w, level: level})
+}
@@ -0,0 +1,46 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"log/syslog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogSeverities(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no UDP loopback: %v", err)
+	}
+	defer conn.Close()
+
+	l, _ := newTestLogger(t, WithConsoleLevel(LevelTrace))
+	if err := l.SetSyslog(LevelDebug, "udp", conn.LocalAddr().String(), syslog.LOG_LOCAL0, "logtest"); err != nil {
+		t.Fatal(err)
+	}
+	defer l.DisableSyslog()
+
+	l.Trace("below the syslog level")
+	l.Debug("d")
+	l.Info("i")
+	l.Warn("w")
+	l.Error("e")
+
+	// LOG_LOCAL0 is 16<<3; severities are LOG_DEBUG (7), LOG_INFO (6), LOG_WARNING (4) and LOG_ERR (3)
+	want := []struct{ pri, msg string }{{"<135>", " - d"}, {"<134>", " - i"}, {"<132>", " - w"}, {"<131>", " - e"}}
+	buf := make([]byte, 4096)
+	for _, w := range want {
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("waiting for %q: %v", w.msg, err)
+		}
+		got := string(buf[:n])
+		if !strings.HasPrefix(got, w.pri) || !strings.Contains(got, "logtest[") || !strings.HasSuffix(strings.TrimSuffix(got, "\n"), w.msg) {
+			t.Errorf("syslog message = %q, want priority %s and message %q", got, w.pri, w.msg)
+		}
+	}
+}
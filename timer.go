@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"runtime"
+	"time"
+)
+
+// Timer starts measuring and returns a function that logs msg at level through the
+// global logger with the elapsed time as the "duration" field:
+//
+//	defer logger.Timer(logger.LevelInfo, "request handled")()
+//
+// Returns a no-op function if the logger is not initialized.
+func Timer(level LogLevel, msg string) func() {
+	return getDefault().timer(level, msg)
+}
+
+// Timer starts measuring and returns a function that logs msg at level with the
+// elapsed time as the "duration" field. The source of the line is where Timer was
+// called, i.e. the defer statement, not the function exit.
+func (l *Logger) Timer(level LogLevel, msg string) func() {
+	return l.timer(level, msg)
+}
+
+// timer implements Timer; it must be called directly by the exported functions
+// to keep the caller depth.
+func (l *Logger) timer(level LogLevel, msg string) func() {
+	if l == nil {
+		return func() {}
+	}
+
+	start := time.Now()
+	pc, file, line, _ := runtime.Caller(2 + l.callerSkip)
+	return func() {
+		elapsed := time.Since(start)
+
+		process, caller := l.plan(level)
+		if !process {
+			return
+		}
+		l := l.forSource(caller, level, file)
+		if l == nil {
+			return
+		}
+		sourceInfo := unknownSource
+		if caller.render() {
+			sourceInfo = caller.format(pc, file, line)
+		}
+		l.WithFields(Fields{"duration": elapsed}).output(level, sourceInfo, msg)
+	}
+}
@@ -0,0 +1,63 @@
+package logger
+
+import "unicode/utf8"
+
+// truncationMark is appended to messages cut by SetMaxMessageLength.
+const truncationMark = "…"
+
+// SetMaxMessageLength limits the length (in characters) of messages logged at level
+// by the global logger. Longer messages are cut and end with "…".
+// n <= 0 removes the limit. Does nothing if the logger is not initialized.
+func SetMaxMessageLength(level LogLevel, n int) {
+	if l := getDefault(); l != nil {
+		l.SetMaxMessageLength(level, n)
+	}
+}
+
+// SetMaxMessageLength limits the length (in characters) of messages logged at level.
+// Longer messages are cut and end with "…". Fields, timestamp and source are not counted.
+// n <= 0 removes the limit for level.
+func (l *Logger) SetMaxMessageLength(level LogLevel, n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if n <= 0 {
+		delete(l.maxMsgLen, level)
+		return
+	}
+	if l.maxMsgLen == nil {
+		l.maxMsgLen = make(map[LogLevel]int)
+	}
+	l.maxMsgLen[level] = n
+}
+
+// truncateMessageLocked applies the length limit of level to msg.
+// Must be called under l.mu.
+func (l *Logger) truncateMessageLocked(level LogLevel, msg string) string {
+	n, ok := l.maxMsgLen[level]
+	if !ok {
+		return msg
+	}
+	return truncateRunes(msg, n)
+}
+
+// truncateRunes cuts s to at most n characters including the truncation mark.
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	keep := n - utf8.RuneCountInString(truncationMark)
+	if keep < 0 {
+		keep = 0
+	}
+
+	i := 0
+	for pos := range s {
+		if i == keep {
+			return s[:pos] + truncationMark
+		}
+		i++
+	}
+	return s + truncationMark
+}
@@ -0,0 +1,41 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+)
+
+// levelWriter adapts a Logger to io.Writer, logging each Write at a fixed level.
+type levelWriter struct {
+	l     *Logger
+	level LogLevel
+}
+
+// Writer returns an io.Writer that logs each Write to the global logger at level.
+// The global logger is looked up on every Write, so the writer keeps working after Reset.
+// Useful for log.SetOutput and http.Server.ErrorLog.
+// Note that the reported caller points into the code calling Write (e.g. the stdlib log package).
+func Writer(level LogLevel) io.Writer {
+	return &levelWriter{level: level}
+}
+
+// Writer returns an io.Writer that logs each Write to this logger at level.
+// A trailing newline (as added by the stdlib log package) is trimmed.
+// Note that the reported caller points into the code calling Write (e.g. the stdlib log package).
+func (l *Logger) Writer(level LogLevel) io.Writer {
+	return &levelWriter{l: l, level: level}
+}
+
+// Write logs p as a single message. It always reports success so callers
+// such as the stdlib log package do not retry or fail.
+func (w *levelWriter) Write(p []byte) (int, error) {
+	l := w.l
+	if l == nil {
+		l = getDefault()
+	}
+
+	msg := bytes.TrimSuffix(p, []byte("\n"))
+	msg = bytes.TrimSuffix(msg, []byte("\r"))
+	l.log(w.level, "%s", msg)
+	return len(p), nil
+}